@@ -0,0 +1,333 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: notebooks_service/service/v1/notebooks.proto
+
+package pb_notebooks_service
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	v1 "notes-rew/api/gen/go/notebooks_service/model/v1"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	NotebooksService_CreateNotebook_FullMethodName      = "/notebooks_service.service.v1.NotebooksService/CreateNotebook"
+	NotebooksService_GetNotebook_FullMethodName         = "/notebooks_service.service.v1.NotebooksService/GetNotebook"
+	NotebooksService_ListNotebooks_FullMethodName       = "/notebooks_service.service.v1.NotebooksService/ListNotebooks"
+	NotebooksService_GetNotebookContents_FullMethodName = "/notebooks_service.service.v1.NotebooksService/GetNotebookContents"
+	NotebooksService_RenameNotebook_FullMethodName      = "/notebooks_service.service.v1.NotebooksService/RenameNotebook"
+	NotebooksService_MoveNotebook_FullMethodName        = "/notebooks_service.service.v1.NotebooksService/MoveNotebook"
+	NotebooksService_DeleteNotebook_FullMethodName      = "/notebooks_service.service.v1.NotebooksService/DeleteNotebook"
+)
+
+// NotebooksServiceClient is the client API for NotebooksService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NotebooksServiceClient interface {
+	CreateNotebook(ctx context.Context, in *v1.CreateNotebookRequest, opts ...grpc.CallOption) (*v1.NotebookIDResponse, error)
+	GetNotebook(ctx context.Context, in *v1.NotebookIDRequest, opts ...grpc.CallOption) (*v1.Notebook, error)
+	ListNotebooks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*v1.ListNotebooksResponse, error)
+	GetNotebookContents(ctx context.Context, in *v1.NotebookIDRequest, opts ...grpc.CallOption) (*v1.NotebookContentsResponse, error)
+	RenameNotebook(ctx context.Context, in *v1.RenameNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	MoveNotebook(ctx context.Context, in *v1.MoveNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteNotebook(ctx context.Context, in *v1.DeleteNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type notebooksServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNotebooksServiceClient(cc grpc.ClientConnInterface) NotebooksServiceClient {
+	return &notebooksServiceClient{cc}
+}
+
+func (c *notebooksServiceClient) CreateNotebook(ctx context.Context, in *v1.CreateNotebookRequest, opts ...grpc.CallOption) (*v1.NotebookIDResponse, error) {
+	out := new(v1.NotebookIDResponse)
+	err := c.cc.Invoke(ctx, NotebooksService_CreateNotebook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) GetNotebook(ctx context.Context, in *v1.NotebookIDRequest, opts ...grpc.CallOption) (*v1.Notebook, error) {
+	out := new(v1.Notebook)
+	err := c.cc.Invoke(ctx, NotebooksService_GetNotebook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) ListNotebooks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*v1.ListNotebooksResponse, error) {
+	out := new(v1.ListNotebooksResponse)
+	err := c.cc.Invoke(ctx, NotebooksService_ListNotebooks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) GetNotebookContents(ctx context.Context, in *v1.NotebookIDRequest, opts ...grpc.CallOption) (*v1.NotebookContentsResponse, error) {
+	out := new(v1.NotebookContentsResponse)
+	err := c.cc.Invoke(ctx, NotebooksService_GetNotebookContents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) RenameNotebook(ctx context.Context, in *v1.RenameNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NotebooksService_RenameNotebook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) MoveNotebook(ctx context.Context, in *v1.MoveNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NotebooksService_MoveNotebook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notebooksServiceClient) DeleteNotebook(ctx context.Context, in *v1.DeleteNotebookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NotebooksService_DeleteNotebook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NotebooksServiceServer is the server API for NotebooksService service.
+// All implementations must embed UnimplementedNotebooksServiceServer
+// for forward compatibility
+type NotebooksServiceServer interface {
+	CreateNotebook(context.Context, *v1.CreateNotebookRequest) (*v1.NotebookIDResponse, error)
+	GetNotebook(context.Context, *v1.NotebookIDRequest) (*v1.Notebook, error)
+	ListNotebooks(context.Context, *emptypb.Empty) (*v1.ListNotebooksResponse, error)
+	GetNotebookContents(context.Context, *v1.NotebookIDRequest) (*v1.NotebookContentsResponse, error)
+	RenameNotebook(context.Context, *v1.RenameNotebookRequest) (*emptypb.Empty, error)
+	MoveNotebook(context.Context, *v1.MoveNotebookRequest) (*emptypb.Empty, error)
+	DeleteNotebook(context.Context, *v1.DeleteNotebookRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedNotebooksServiceServer()
+}
+
+// UnimplementedNotebooksServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedNotebooksServiceServer struct {
+}
+
+func (UnimplementedNotebooksServiceServer) CreateNotebook(context.Context, *v1.CreateNotebookRequest) (*v1.NotebookIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateNotebook not implemented")
+}
+func (UnimplementedNotebooksServiceServer) GetNotebook(context.Context, *v1.NotebookIDRequest) (*v1.Notebook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotebook not implemented")
+}
+func (UnimplementedNotebooksServiceServer) ListNotebooks(context.Context, *emptypb.Empty) (*v1.ListNotebooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotebooks not implemented")
+}
+func (UnimplementedNotebooksServiceServer) GetNotebookContents(context.Context, *v1.NotebookIDRequest) (*v1.NotebookContentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotebookContents not implemented")
+}
+func (UnimplementedNotebooksServiceServer) RenameNotebook(context.Context, *v1.RenameNotebookRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameNotebook not implemented")
+}
+func (UnimplementedNotebooksServiceServer) MoveNotebook(context.Context, *v1.MoveNotebookRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveNotebook not implemented")
+}
+func (UnimplementedNotebooksServiceServer) DeleteNotebook(context.Context, *v1.DeleteNotebookRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteNotebook not implemented")
+}
+func (UnimplementedNotebooksServiceServer) mustEmbedUnimplementedNotebooksServiceServer() {}
+
+// UnsafeNotebooksServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NotebooksServiceServer will
+// result in compilation errors.
+type UnsafeNotebooksServiceServer interface {
+	mustEmbedUnimplementedNotebooksServiceServer()
+}
+
+func RegisterNotebooksServiceServer(s grpc.ServiceRegistrar, srv NotebooksServiceServer) {
+	s.RegisterService(&NotebooksService_ServiceDesc, srv)
+}
+
+func _NotebooksService_CreateNotebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.CreateNotebookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).CreateNotebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_CreateNotebook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).CreateNotebook(ctx, req.(*v1.CreateNotebookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_GetNotebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.NotebookIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).GetNotebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_GetNotebook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).GetNotebook(ctx, req.(*v1.NotebookIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_ListNotebooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).ListNotebooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_ListNotebooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).ListNotebooks(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_GetNotebookContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.NotebookIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).GetNotebookContents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_GetNotebookContents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).GetNotebookContents(ctx, req.(*v1.NotebookIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_RenameNotebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.RenameNotebookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).RenameNotebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_RenameNotebook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).RenameNotebook(ctx, req.(*v1.RenameNotebookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_MoveNotebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.MoveNotebookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).MoveNotebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_MoveNotebook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).MoveNotebook(ctx, req.(*v1.MoveNotebookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotebooksService_DeleteNotebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.DeleteNotebookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotebooksServiceServer).DeleteNotebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotebooksService_DeleteNotebook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotebooksServiceServer).DeleteNotebook(ctx, req.(*v1.DeleteNotebookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NotebooksService_ServiceDesc is the grpc.ServiceDesc for NotebooksService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NotebooksService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "notebooks_service.service.v1.NotebooksService",
+	HandlerType: (*NotebooksServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateNotebook",
+			Handler:    _NotebooksService_CreateNotebook_Handler,
+		},
+		{
+			MethodName: "GetNotebook",
+			Handler:    _NotebooksService_GetNotebook_Handler,
+		},
+		{
+			MethodName: "ListNotebooks",
+			Handler:    _NotebooksService_ListNotebooks_Handler,
+		},
+		{
+			MethodName: "GetNotebookContents",
+			Handler:    _NotebooksService_GetNotebookContents_Handler,
+		},
+		{
+			MethodName: "RenameNotebook",
+			Handler:    _NotebooksService_RenameNotebook_Handler,
+		},
+		{
+			MethodName: "MoveNotebook",
+			Handler:    _NotebooksService_MoveNotebook_Handler,
+		},
+		{
+			MethodName: "DeleteNotebook",
+			Handler:    _NotebooksService_DeleteNotebook_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "notebooks_service/service/v1/notebooks.proto",
+}
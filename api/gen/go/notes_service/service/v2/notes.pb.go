@@ -0,0 +1,150 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: notes_service/service/v2/notes.proto
+
+package pb_notes_service
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	v2 "notes-rew/api/gen/go/notes_service/model/v2"
+	reflect "reflect"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_notes_service_service_v2_notes_proto protoreflect.FileDescriptor
+
+var file_notes_service_service_v2_notes_proto_rawDesc = []byte{
+	0x0a, 0x24, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x76, 0x32, 0x2f, 0x6e, 0x6f, 0x74, 0x65, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x18, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x76, 0x32,
+	0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x22, 0x6e,
+	0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x6d, 0x6f, 0x64,
+	0x65, 0x6c, 0x2f, 0x76, 0x32, 0x2f, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x32, 0xdf, 0x05, 0x0a, 0x0c, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x60, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x12,
+	0x28, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4e, 0x6f, 0x74,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x6e, 0x6f, 0x74, 0x65,
+	0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e,
+	0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x66, 0x0a, 0x0b, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4e, 0x6f,
+	0x74, 0x65, 0x73, 0x12, 0x2a, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x2b, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4e,
+	0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x07,
+	0x47, 0x65, 0x74, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x26, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32,
+	0x2e, 0x47, 0x65, 0x74, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1c, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e,
+	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x5d, 0x0a,
+	0x0a, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x29, 0x2e, 0x6e, 0x6f,
+	0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65,
+	0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x4e, 0x6f, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e,
+	0x52, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x65, 0x64, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x55, 0x0a, 0x0a,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x29, 0x2e, 0x6e, 0x6f, 0x74,
+	0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x2e, 0x76, 0x32, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x4e,
+	0x6f, 0x74, 0x65, 0x12, 0x53, 0x0a, 0x09, 0x50, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65,
+	0x12, 0x28, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x4e,
+	0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6e, 0x6f, 0x74,
+	0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x2e, 0x76, 0x32, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x12, 0x4b, 0x0a, 0x08, 0x4d, 0x6f, 0x76, 0x65,
+	0x4e, 0x6f, 0x74, 0x65, 0x12, 0x27, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x4d, 0x6f,
+	0x76, 0x65, 0x4e, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x5c, 0x0a, 0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f,
+	0x74, 0x65, 0x73, 0x12, 0x29, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x4e, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21,
+	0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x6d,
+	0x6f, 0x64, 0x65, 0x6c, 0x2e, 0x76, 0x32, 0x2e, 0x4e, 0x6f, 0x74, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x30, 0x01, 0x42, 0x40, 0x5a, 0x3e, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x2d, 0x72, 0x65, 0x77,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x67, 0x6f, 0x2f, 0x6e, 0x6f, 0x74, 0x65,
+	0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x2f, 0x76, 0x32, 0x3b, 0x70, 0x62, 0x5f, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var file_notes_service_service_v2_notes_proto_goTypes = []interface{}{
+	(*v2.ListNotesRequest)(nil),    // 0: notes_service.model.v2.ListNotesRequest
+	(*v2.SearchNotesRequest)(nil),  // 1: notes_service.model.v2.SearchNotesRequest
+	(*v2.GetNoteRequest)(nil),      // 2: notes_service.model.v2.GetNoteRequest
+	(*v2.RenderNoteRequest)(nil),   // 3: notes_service.model.v2.RenderNoteRequest
+	(*v2.UpdateNoteRequest)(nil),   // 4: notes_service.model.v2.UpdateNoteRequest
+	(*v2.PatchNoteRequest)(nil),    // 5: notes_service.model.v2.PatchNoteRequest
+	(*v2.MoveNoteRequest)(nil),     // 6: notes_service.model.v2.MoveNoteRequest
+	(*v2.WatchNotesRequest)(nil),   // 7: notes_service.model.v2.WatchNotesRequest
+	(*v2.ListNotesResponse)(nil),   // 8: notes_service.model.v2.ListNotesResponse
+	(*v2.SearchNotesResponse)(nil), // 9: notes_service.model.v2.SearchNotesResponse
+	(*v2.Note)(nil),                // 10: notes_service.model.v2.Note
+	(*v2.RenderedNote)(nil),        // 11: notes_service.model.v2.RenderedNote
+	(*emptypb.Empty)(nil),          // 12: google.protobuf.Empty
+	(*v2.NoteEvent)(nil),           // 13: notes_service.model.v2.NoteEvent
+}
+var file_notes_service_service_v2_notes_proto_depIdxs = []int32{
+	0,  // 0: notes_service.service.v2.NotesService.ListNotes:input_type -> notes_service.model.v2.ListNotesRequest
+	1,  // 1: notes_service.service.v2.NotesService.SearchNotes:input_type -> notes_service.model.v2.SearchNotesRequest
+	2,  // 2: notes_service.service.v2.NotesService.GetNote:input_type -> notes_service.model.v2.GetNoteRequest
+	3,  // 3: notes_service.service.v2.NotesService.RenderNote:input_type -> notes_service.model.v2.RenderNoteRequest
+	4,  // 4: notes_service.service.v2.NotesService.UpdateNote:input_type -> notes_service.model.v2.UpdateNoteRequest
+	5,  // 5: notes_service.service.v2.NotesService.PatchNote:input_type -> notes_service.model.v2.PatchNoteRequest
+	6,  // 6: notes_service.service.v2.NotesService.MoveNote:input_type -> notes_service.model.v2.MoveNoteRequest
+	7,  // 7: notes_service.service.v2.NotesService.WatchNotes:input_type -> notes_service.model.v2.WatchNotesRequest
+	8,  // 8: notes_service.service.v2.NotesService.ListNotes:output_type -> notes_service.model.v2.ListNotesResponse
+	9,  // 9: notes_service.service.v2.NotesService.SearchNotes:output_type -> notes_service.model.v2.SearchNotesResponse
+	10, // 10: notes_service.service.v2.NotesService.GetNote:output_type -> notes_service.model.v2.Note
+	11, // 11: notes_service.service.v2.NotesService.RenderNote:output_type -> notes_service.model.v2.RenderedNote
+	10, // 12: notes_service.service.v2.NotesService.UpdateNote:output_type -> notes_service.model.v2.Note
+	10, // 13: notes_service.service.v2.NotesService.PatchNote:output_type -> notes_service.model.v2.Note
+	12, // 14: notes_service.service.v2.NotesService.MoveNote:output_type -> google.protobuf.Empty
+	13, // 15: notes_service.service.v2.NotesService.WatchNotes:output_type -> notes_service.model.v2.NoteEvent
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_notes_service_service_v2_notes_proto_init() }
+func file_notes_service_service_v2_notes_proto_init() {
+	if File_notes_service_service_v2_notes_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_notes_service_service_v2_notes_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_notes_service_service_v2_notes_proto_goTypes,
+		DependencyIndexes: file_notes_service_service_v2_notes_proto_depIdxs,
+	}.Build()
+	File_notes_service_service_v2_notes_proto = out.File
+	file_notes_service_service_v2_notes_proto_rawDesc = nil
+	file_notes_service_service_v2_notes_proto_goTypes = nil
+	file_notes_service_service_v2_notes_proto_depIdxs = nil
+}
@@ -0,0 +1,398 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: notes_service/service/v2/notes.proto
+
+package pb_notes_service
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	v2 "notes-rew/api/gen/go/notes_service/model/v2"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	NotesService_ListNotes_FullMethodName   = "/notes_service.service.v2.NotesService/ListNotes"
+	NotesService_SearchNotes_FullMethodName = "/notes_service.service.v2.NotesService/SearchNotes"
+	NotesService_GetNote_FullMethodName     = "/notes_service.service.v2.NotesService/GetNote"
+	NotesService_RenderNote_FullMethodName  = "/notes_service.service.v2.NotesService/RenderNote"
+	NotesService_UpdateNote_FullMethodName  = "/notes_service.service.v2.NotesService/UpdateNote"
+	NotesService_PatchNote_FullMethodName   = "/notes_service.service.v2.NotesService/PatchNote"
+	NotesService_MoveNote_FullMethodName    = "/notes_service.service.v2.NotesService/MoveNote"
+	NotesService_WatchNotes_FullMethodName  = "/notes_service.service.v2.NotesService/WatchNotes"
+)
+
+// NotesServiceClient is the client API for NotesService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NotesServiceClient interface {
+	ListNotes(ctx context.Context, in *v2.ListNotesRequest, opts ...grpc.CallOption) (*v2.ListNotesResponse, error)
+	SearchNotes(ctx context.Context, in *v2.SearchNotesRequest, opts ...grpc.CallOption) (*v2.SearchNotesResponse, error)
+	GetNote(ctx context.Context, in *v2.GetNoteRequest, opts ...grpc.CallOption) (*v2.Note, error)
+	RenderNote(ctx context.Context, in *v2.RenderNoteRequest, opts ...grpc.CallOption) (*v2.RenderedNote, error)
+	UpdateNote(ctx context.Context, in *v2.UpdateNoteRequest, opts ...grpc.CallOption) (*v2.Note, error)
+	PatchNote(ctx context.Context, in *v2.PatchNoteRequest, opts ...grpc.CallOption) (*v2.Note, error)
+	MoveNote(ctx context.Context, in *v2.MoveNoteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	WatchNotes(ctx context.Context, in *v2.WatchNotesRequest, opts ...grpc.CallOption) (NotesService_WatchNotesClient, error)
+}
+
+type notesServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNotesServiceClient(cc grpc.ClientConnInterface) NotesServiceClient {
+	return &notesServiceClient{cc}
+}
+
+func (c *notesServiceClient) ListNotes(ctx context.Context, in *v2.ListNotesRequest, opts ...grpc.CallOption) (*v2.ListNotesResponse, error) {
+	out := new(v2.ListNotesResponse)
+	err := c.cc.Invoke(ctx, NotesService_ListNotes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) SearchNotes(ctx context.Context, in *v2.SearchNotesRequest, opts ...grpc.CallOption) (*v2.SearchNotesResponse, error) {
+	out := new(v2.SearchNotesResponse)
+	err := c.cc.Invoke(ctx, NotesService_SearchNotes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) GetNote(ctx context.Context, in *v2.GetNoteRequest, opts ...grpc.CallOption) (*v2.Note, error) {
+	out := new(v2.Note)
+	err := c.cc.Invoke(ctx, NotesService_GetNote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) RenderNote(ctx context.Context, in *v2.RenderNoteRequest, opts ...grpc.CallOption) (*v2.RenderedNote, error) {
+	out := new(v2.RenderedNote)
+	err := c.cc.Invoke(ctx, NotesService_RenderNote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) UpdateNote(ctx context.Context, in *v2.UpdateNoteRequest, opts ...grpc.CallOption) (*v2.Note, error) {
+	out := new(v2.Note)
+	err := c.cc.Invoke(ctx, NotesService_UpdateNote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) PatchNote(ctx context.Context, in *v2.PatchNoteRequest, opts ...grpc.CallOption) (*v2.Note, error) {
+	out := new(v2.Note)
+	err := c.cc.Invoke(ctx, NotesService_PatchNote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) MoveNote(ctx context.Context, in *v2.MoveNoteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NotesService_MoveNote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notesServiceClient) WatchNotes(ctx context.Context, in *v2.WatchNotesRequest, opts ...grpc.CallOption) (NotesService_WatchNotesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NotesService_ServiceDesc.Streams[0], NotesService_WatchNotes_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &notesServiceWatchNotesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NotesService_WatchNotesClient interface {
+	Recv() (*v2.NoteEvent, error)
+	grpc.ClientStream
+}
+
+type notesServiceWatchNotesClient struct {
+	grpc.ClientStream
+}
+
+func (x *notesServiceWatchNotesClient) Recv() (*v2.NoteEvent, error) {
+	m := new(v2.NoteEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NotesServiceServer is the server API for NotesService service.
+// All implementations must embed UnimplementedNotesServiceServer
+// for forward compatibility
+type NotesServiceServer interface {
+	ListNotes(context.Context, *v2.ListNotesRequest) (*v2.ListNotesResponse, error)
+	SearchNotes(context.Context, *v2.SearchNotesRequest) (*v2.SearchNotesResponse, error)
+	GetNote(context.Context, *v2.GetNoteRequest) (*v2.Note, error)
+	RenderNote(context.Context, *v2.RenderNoteRequest) (*v2.RenderedNote, error)
+	UpdateNote(context.Context, *v2.UpdateNoteRequest) (*v2.Note, error)
+	PatchNote(context.Context, *v2.PatchNoteRequest) (*v2.Note, error)
+	MoveNote(context.Context, *v2.MoveNoteRequest) (*emptypb.Empty, error)
+	WatchNotes(*v2.WatchNotesRequest, NotesService_WatchNotesServer) error
+	mustEmbedUnimplementedNotesServiceServer()
+}
+
+// UnimplementedNotesServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedNotesServiceServer struct {
+}
+
+func (UnimplementedNotesServiceServer) ListNotes(context.Context, *v2.ListNotesRequest) (*v2.ListNotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotes not implemented")
+}
+func (UnimplementedNotesServiceServer) SearchNotes(context.Context, *v2.SearchNotesRequest) (*v2.SearchNotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchNotes not implemented")
+}
+func (UnimplementedNotesServiceServer) GetNote(context.Context, *v2.GetNoteRequest) (*v2.Note, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNote not implemented")
+}
+func (UnimplementedNotesServiceServer) RenderNote(context.Context, *v2.RenderNoteRequest) (*v2.RenderedNote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderNote not implemented")
+}
+func (UnimplementedNotesServiceServer) UpdateNote(context.Context, *v2.UpdateNoteRequest) (*v2.Note, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNote not implemented")
+}
+func (UnimplementedNotesServiceServer) PatchNote(context.Context, *v2.PatchNoteRequest) (*v2.Note, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PatchNote not implemented")
+}
+func (UnimplementedNotesServiceServer) MoveNote(context.Context, *v2.MoveNoteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveNote not implemented")
+}
+func (UnimplementedNotesServiceServer) WatchNotes(*v2.WatchNotesRequest, NotesService_WatchNotesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchNotes not implemented")
+}
+func (UnimplementedNotesServiceServer) mustEmbedUnimplementedNotesServiceServer() {}
+
+// UnsafeNotesServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NotesServiceServer will
+// result in compilation errors.
+type UnsafeNotesServiceServer interface {
+	mustEmbedUnimplementedNotesServiceServer()
+}
+
+func RegisterNotesServiceServer(s grpc.ServiceRegistrar, srv NotesServiceServer) {
+	s.RegisterService(&NotesService_ServiceDesc, srv)
+}
+
+func _NotesService_ListNotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.ListNotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).ListNotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_ListNotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).ListNotes(ctx, req.(*v2.ListNotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_SearchNotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.SearchNotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).SearchNotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_SearchNotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).SearchNotes(ctx, req.(*v2.SearchNotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_GetNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.GetNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).GetNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_GetNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).GetNote(ctx, req.(*v2.GetNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_RenderNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.RenderNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).RenderNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_RenderNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).RenderNote(ctx, req.(*v2.RenderNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_UpdateNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.UpdateNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).UpdateNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_UpdateNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).UpdateNote(ctx, req.(*v2.UpdateNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_PatchNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.PatchNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).PatchNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_PatchNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).PatchNote(ctx, req.(*v2.PatchNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_MoveNote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v2.MoveNoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotesServiceServer).MoveNote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotesService_MoveNote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotesServiceServer).MoveNote(ctx, req.(*v2.MoveNoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotesService_WatchNotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(v2.WatchNotesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NotesServiceServer).WatchNotes(m, &notesServiceWatchNotesServer{stream})
+}
+
+type NotesService_WatchNotesServer interface {
+	Send(*v2.NoteEvent) error
+	grpc.ServerStream
+}
+
+type notesServiceWatchNotesServer struct {
+	grpc.ServerStream
+}
+
+func (x *notesServiceWatchNotesServer) Send(m *v2.NoteEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// NotesService_ServiceDesc is the grpc.ServiceDesc for NotesService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NotesService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "notes_service.service.v2.NotesService",
+	HandlerType: (*NotesServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNotes",
+			Handler:    _NotesService_ListNotes_Handler,
+		},
+		{
+			MethodName: "SearchNotes",
+			Handler:    _NotesService_SearchNotes_Handler,
+		},
+		{
+			MethodName: "GetNote",
+			Handler:    _NotesService_GetNote_Handler,
+		},
+		{
+			MethodName: "RenderNote",
+			Handler:    _NotesService_RenderNote_Handler,
+		},
+		{
+			MethodName: "UpdateNote",
+			Handler:    _NotesService_UpdateNote_Handler,
+		},
+		{
+			MethodName: "PatchNote",
+			Handler:    _NotesService_PatchNote_Handler,
+		},
+		{
+			MethodName: "MoveNote",
+			Handler:    _NotesService_MoveNote_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchNotes",
+			Handler:       _NotesService_WatchNotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "notes_service/service/v2/notes.proto",
+}
@@ -72,6 +72,24 @@ func main() {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		newApp.StartTrashPurger(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		newApp.StartImportRunner(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		newApp.StartNoteEvents(ctx)
+	}()
+
 	wg.Wait()
 
 }
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
@@ -18,10 +19,16 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	pb_auth_service_v2 "notes-rew/api/gen/go/auth_service/service/v2"
+	pb_notebooks_service "notes-rew/api/gen/go/notebooks_service/service/v1"
+	pb_notes_service_v2 "notes-rew/api/gen/go/notes_service/service/v2"
 	authControllerGRPC "notes-rew/internal/auth_service/controller/grpc/v1"
+	authControllerGRPCv2 "notes-rew/internal/auth_service/controller/grpc/v2"
 	"notes-rew/internal/db/redis"
 	"notes-rew/internal/middlewares"
+	notebooksControllerGRPC "notes-rew/internal/notebooks_service/controller/grpc/v1"
 	notesControllerGRPC "notes-rew/internal/notes_service/controller/grpc/v1"
+	notesControllerGRPCv2 "notes-rew/internal/notes_service/controller/grpc/v2"
 	usersControllerGRPC "notes-rew/internal/users_service/controller/grpc/v1"
 
 	"github.com/go-playground/validator/v10"
@@ -31,13 +38,23 @@ import (
 	authService "notes-rew/internal/auth_service/service"
 	authStorage "notes-rew/internal/auth_service/storage/postgres"
 	authUsecase "notes-rew/internal/auth_service/usecase"
+	"notes-rew/internal/blobstore"
 	"notes-rew/internal/config"
 	"notes-rew/internal/db/postgres"
+	"notes-rew/internal/db/transactor"
 	"notes-rew/internal/hash"
+	"notes-rew/internal/mailer"
+	notebooksController "notes-rew/internal/notebooks_service/controller/rest/handler"
+	notebooksService "notes-rew/internal/notebooks_service/service"
+	notebooksStorage "notes-rew/internal/notebooks_service/storage/postgres"
+	notebooksUsecase "notes-rew/internal/notebooks_service/usecase"
+	notesCache "notes-rew/internal/notes_service/cache"
 	notesController "notes-rew/internal/notes_service/controller/rest/handler"
+	notesEvents "notes-rew/internal/notes_service/events"
 	notesService "notes-rew/internal/notes_service/service"
 	notesStorage "notes-rew/internal/notes_service/storage/postgres"
 	notesUsecase "notes-rew/internal/notes_service/usecase"
+	notesWorker "notes-rew/internal/notes_service/worker"
 	"notes-rew/internal/token_manager"
 	usersController "notes-rew/internal/users_service/controller/rest/handler"
 	usersService "notes-rew/internal/users_service/service"
@@ -57,9 +74,12 @@ const (
 )
 
 type grpcService struct {
-	auth  pb_auth_service.AuthServiceServer
-	users pb_users_service.UsersServiceServer
-	notes pb_notes_service.NotesServiceServer
+	auth      pb_auth_service.AuthServiceServer
+	authV2    pb_auth_service_v2.AuthServiceServer
+	users     pb_users_service.UsersServiceServer
+	notes     pb_notes_service.NotesServiceServer
+	notesV2   pb_notes_service_v2.NotesServiceServer
+	notebooks pb_notebooks_service.NotebooksServiceServer
 }
 
 type App struct {
@@ -68,6 +88,9 @@ type App struct {
 	mux          *runtime.ServeMux
 	cfg          config.Config
 	tokenManager *token_manager.TokenManager
+	trashPurger  *notesWorker.TrashPurger
+	importRunner *notesWorker.ImportRunner
+	noteEvents   *notesEvents.Bus
 }
 
 func NewApp(ctx context.Context, cfg config.Config) *App {
@@ -77,7 +100,7 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 	}
 
 	api := operations.NewNotesAPIAPI(spec)
-	swaggerServer := restapi.NewServer(api)
+	_ = restapi.NewServer(api)
 
 	// api.Logger = logging.L(context.Background()).Sugar().Infof
 	//
@@ -134,11 +157,14 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(requestTimeout))
 
-	router.Mount("/debug", middleware.Profiler())
+	// Streamed responses are registered on router directly, everything else
+	// on timedRouter, which times requests out.
+	timedRouter := router.With(middleware.Timeout(requestTimeout))
 
-	router.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(swaggerURL)))
+	timedRouter.Mount("/debug", middleware.Profiler())
+
+	timedRouter.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(swaggerURL)))
 
 	mux := runtime.NewServeMux()
 
@@ -151,23 +177,81 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 		logrus.Errorf("Failed to migrate: %+v", err)
 	}
 
+	// The note cache degrades to the database while Redis is away, and the
+	// client reconnects on its own, so an unreachable Redis isn't fatal.
 	connectRedis, err := redis.ConnectionRedisStorage(ctx, cfg)
 	if err != nil {
-		logrus.Fatalf("Failed to connect to Redis: %+v", err)
+		logrus.Errorf("Failed to connect to Redis: %+v", err)
 	}
 
+	noteCache := notesCache.NewNoteCache(connectRedis, cfg.Cache.NoteTTL, cfg.Cache.ListTTL)
+
 	validation := validator.New()
 	validators.RegisterCustomValidation(validation)
 
-	tokenManager := token_manager.NewTokenManager(cfg.JwtSigning)
+	signing := token_manager.Signing{
+		Secret:   cfg.JwtSigning,
+		Issuer:   cfg.Auth.JWTIssuer,
+		Audience: cfg.Auth.JWTAudience,
+	}
+
+	if cfg.Auth.JWTKeySet != "" {
+		signing.Keys, err = token_manager.LoadKeySet(cfg.Auth.JWTKeySet)
+		if err != nil {
+			logrus.Fatalf("Failed to load JWT key set: %+v", err)
+		}
+	} else {
+		logrus.Warn("auth.jwt_key_set is not set, signing access tokens with HS256")
+	}
+
+	tokenManager := token_manager.NewTokenManager(
+		signing,
+		cfg.Auth.AccessTokenTTL,
+		cfg.Auth.RefreshTokenTTL,
+		token_manager.NewRedisRevocationStore(connectRedis),
+		token_manager.NewPostgresPersonalTokenStore(connectDB),
+	)
 
 	hasher := hash.NewPasswordHasher(cfg.SaltHash)
 
+	switch cfg.Auth.EmailVerification {
+	case "off", "login", "notes":
+	default:
+		logrus.Fatalf("Unknown auth.email_verification %q", cfg.Auth.EmailVerification)
+	}
+
+	dbTransactor := transactor.NewTransactor(connectDB)
+
 	noteStorage := notesStorage.NewNoteStorage(connectDB)
-	noteService := notesService.NewNoteService(noteStorage, connectRedis)
-	noteUsecase := notesUsecase.NewNoteUsecase(noteService)
-	noteController := notesController.NewNoteController(noteUsecase, validation, tokenManager)
-	noteController.Register(router)
+	noteService := notesService.NewNoteService(noteStorage, noteCache)
+	attachmentStore, err := blobstore.NewLocalStore(cfg.Attachments.Dir)
+	if err != nil {
+		logrus.Fatalf("Failed to open attachment store: %+v", err)
+	}
+
+	uploadLimits := notesUsecase.UploadLimits{
+		MaxAttachmentSize: cfg.Attachments.MaxFileSize,
+		AttachmentQuota:   cfg.Attachments.UserQuota,
+		MaxImportSize:     cfg.Import.MaxFileSize,
+	}
+
+	noteEvents := notesEvents.NewBus(connectRedis)
+
+	noteUsecase := notesUsecase.NewNoteUsecase(
+		noteService,
+		hasher,
+		dbTransactor,
+		attachmentStore,
+		uploadLimits,
+		noteEvents,
+		cfg.Auth.EmailVerification == "notes",
+	)
+	noteController := notesController.NewNoteController(noteUsecase, validation, tokenManager, uploadLimits)
+	noteController.Register(timedRouter)
+	noteController.RegisterStreams(router)
+
+	trashPurger := notesWorker.NewTrashPurger(noteUsecase, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	importRunner := notesWorker.NewImportRunner(noteUsecase, cfg.Import.PollInterval, cfg.Import.StaleAfter)
 
 	noteControllerGRPC := notesControllerGRPC.NewNotesServer(
 		noteUsecase,
@@ -175,11 +259,27 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 		pb_notes_service.UnimplementedNotesServiceServer{},
 	)
 
+	noteControllerGRPCv2 := notesControllerGRPCv2.NewNotesServer(
+		noteUsecase,
+		pb_notes_service_v2.UnimplementedNotesServiceServer{},
+	)
+
+	notebookStorage := notebooksStorage.NewNotebookStorage(connectDB)
+	notebookService := notebooksService.NewNotebookService(notebookStorage, noteCache)
+	notebookUsecase := notebooksUsecase.NewNotebookUsecase(notebookService)
+	notebookController := notebooksController.NewNotebookController(notebookUsecase, validation, tokenManager)
+	notebookController.Register(timedRouter)
+
+	notebookControllerGRPC := notebooksControllerGRPC.NewNotebooksServer(
+		notebookUsecase,
+		pb_notebooks_service.UnimplementedNotebooksServiceServer{},
+	)
+
 	userStorage := usersStorage.NewPSQLUserStorage(connectDB)
 	userService := usersService.NewUserService(userStorage)
 	userUsecase := usersUsecase.NewUserUsecase(userService, hasher)
 	userController := usersController.NewUserController(userUsecase, tokenManager, validation)
-	userController.Register(router)
+	userController.Register(timedRouter)
 
 	userControllerGRPC := usersControllerGRPC.NewUsersServer(
 		userUsecase,
@@ -189,9 +289,37 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 
 	authsStorage := authStorage.NewUserStorage(connectDB)
 	authsService := authService.NewAuthService(authsStorage)
-	authsUsecase := authUsecase.NewAuthUsecase(authsService, hasher, tokenManager)
-	authsController := authController.NewAuthController(authsUsecase, validation)
-	authsController.Register(router)
+	accountMailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		logrus.Fatalf("Failed to set up mail: %+v", err)
+	}
+
+	verificationSecret := cfg.Auth.VerificationSecret
+	if verificationSecret == "" {
+		verificationSecret = cfg.JwtSigning
+	}
+
+	authsUsecase := authUsecase.NewAuthUsecase(
+		authsService,
+		hasher,
+		tokenManager,
+		dbTransactor,
+		accountMailer,
+		authUsecase.AccountSettings{
+			PasswordResetURL: cfg.Auth.PasswordResetURL,
+			PasswordResetTTL: cfg.Auth.PasswordResetTTL,
+
+			VerifyEmailURL:       cfg.Auth.VerifyEmailURL,
+			VerifyEmailTTL:       cfg.Auth.VerifyEmailTTL,
+			VerificationSecret:   []byte(verificationSecret),
+			RequireVerifiedEmail: cfg.Auth.EmailVerification == "login",
+
+			TwoFactorIssuer:       cfg.Auth.TwoFactorIssuer,
+			TwoFactorChallengeTTL: cfg.Auth.TwoFactorChallengeTTL,
+		},
+	)
+	authsController := authController.NewAuthController(authsUsecase, validation, tokenManager)
+	authsController.Register(timedRouter)
 
 	authsControllerGRPC := authControllerGRPC.NewAuthServer(
 		authsUsecase,
@@ -199,19 +327,44 @@ func NewApp(ctx context.Context, cfg config.Config) *App {
 		pb_auth_service.UnimplementedAuthServiceServer{},
 	)
 
+	authsControllerGRPCv2 := authControllerGRPCv2.NewAuthServer(
+		authsUsecase,
+		validation,
+		pb_auth_service_v2.UnimplementedAuthServiceServer{},
+	)
+
 	return &App{
 		router:       router,
 		mux:          mux,
 		cfg:          cfg,
 		tokenManager: tokenManager,
+		trashPurger:  trashPurger,
+		importRunner: importRunner,
+		noteEvents:   noteEvents,
 		protoService: grpcService{
-			auth:  authsControllerGRPC,
-			users: userControllerGRPC,
-			notes: noteControllerGRPC,
+			auth:      authsControllerGRPC,
+			authV2:    authsControllerGRPCv2,
+			users:     userControllerGRPC,
+			notes:     noteControllerGRPC,
+			notesV2:   noteControllerGRPCv2,
+			notebooks: notebookControllerGRPC,
 		},
 	}
 }
 
+// newMailer sends mail through SMTP, or keeps it in a local outbox
+// directory when cfg.Driver is file.
+func newMailer(cfg config.Mail) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From), nil
+	case "file":
+		return mailer.NewFileOutbox(cfg.OutboxDir, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
 func (a *App) Start(ctx context.Context) error {
 	httpServer := &http.Server{
 		Addr:           a.cfg.HTTPServer.Address,
@@ -240,18 +393,47 @@ func (a *App) Start(ctx context.Context) error {
 	return httpServer.Shutdown(shutdownCtx)
 }
 
+// StartTrashPurger permanently deletes notes that stayed in the trash longer
+// than the configured retention period. It blocks until ctx is cancelled.
+func (a *App) StartTrashPurger(ctx context.Context) {
+	logrus.Println("Trash purger started, retention:", a.cfg.Trash.Retention)
+
+	a.trashPurger.Run(ctx)
+}
+
+// StartImportRunner runs queued note imports. It blocks until ctx is
+// cancelled.
+func (a *App) StartImportRunner(ctx context.Context) {
+	logrus.Println("Import runner started")
+
+	a.importRunner.Run(ctx)
+}
+
+// StartNoteEvents relays note events published by other instances to the
+// clients watching this one. It blocks until ctx is cancelled.
+func (a *App) StartNoteEvents(ctx context.Context) {
+	logrus.Println("Note event relay started")
+
+	a.noteEvents.Run(ctx)
+}
+
 func (a *App) StartGRPC() error {
 	listener, err := net.Listen("tcp", a.cfg.GRPCServer.Address)
 	if err != nil {
 		logrus.Fatalf("Failed to listen: %+v", err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		middlewares.UnaryTokenInterceptor(a.tokenManager)))
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middlewares.UnaryTokenInterceptor(a.tokenManager)),
+		grpc.ChainStreamInterceptor(middlewares.StreamTokenInterceptor(a.tokenManager)),
+	)
 
 	pb_auth_service.RegisterAuthServiceServer(grpcServer, a.protoService.auth)
+	pb_auth_service_v2.RegisterAuthServiceServer(grpcServer, a.protoService.authV2)
 	pb_users_service.RegisterUsersServiceServer(grpcServer, a.protoService.users)
 	pb_notes_service.RegisterNotesServiceServer(grpcServer, a.protoService.notes)
+	pb_notes_service_v2.RegisterNotesServiceServer(grpcServer, a.protoService.notesV2)
+	pb_notebooks_service.RegisterNotebooksServiceServer(grpcServer, a.protoService.notebooks)
 
 	reflection.Register(grpcServer)
 
@@ -275,6 +457,8 @@ func (a *App) StartGateway(ctx context.Context) error {
 		return err
 	}
 
+	// The v2 NotesService is left out: its protos have no HTTP bindings to
+	// generate a gateway from, and the REST API serves the same operations.
 	err = pb_notes_service.RegisterNotesServiceHandlerServer(ctx, a.mux, a.protoService.notes)
 	if err != nil {
 		return err
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"errors"
 	pb_model "github.com/almalii/grpc-contracts/gen/go/auth_service/model/v1"
 	pb_service "github.com/almalii/grpc-contracts/gen/go/auth_service/service/v1"
 	"github.com/go-playground/validator/v10"
@@ -53,9 +54,17 @@ func (s *AuthServer) SignIn(ctx context.Context, req *pb_model.SignInRequest) (*
 
 	authData, err := s.usecase.AuthenticateUser(ctx, input)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailNotVerified) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		logrus.Error("password is not correct")
 		return nil, status.Errorf(codes.Unauthenticated, "password is not correct")
 	}
+
+	// The v1 response has no room for a challenge.
+	if authData.TwoFactorRequired {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is enabled, log in with AuthService v2")
+	}
 	
 	resp := NewSignInResponse(authData.Token)
 
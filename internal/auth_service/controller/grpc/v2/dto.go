@@ -0,0 +1,35 @@
+package v2
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb_auth_model "notes-rew/api/gen/go/auth_service/model/v2"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/usecase"
+)
+
+func NewLoginInput(req *pb_auth_model.LoginRequest) usecase.AuthInput {
+	return usecase.AuthInput{
+		Email:    strings.ToLower(req.Email),
+		Password: req.Password,
+	}
+}
+
+func NewTokenPair(resp *models.AuthResponse) *pb_auth_model.TokenPair {
+	return &pb_auth_model.TokenPair{
+		AccessToken:          resp.Token,
+		RefreshToken:         resp.RefreshToken,
+		AccessTokenExpiresAt: timestamppb.New(resp.ExpiresAt),
+		TwoFactorRequired:    resp.TwoFactorRequired,
+		ChallengeToken:       resp.ChallengeToken,
+	}
+}
+
+func NewTwoFactorEnrollment(enrollment *models.TwoFactorEnrollment) *pb_auth_model.TwoFactorEnrollment {
+	return &pb_auth_model.TwoFactorEnrollment{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.ProvisioningURI,
+		QrCode:          enrollment.QRCode,
+	}
+}
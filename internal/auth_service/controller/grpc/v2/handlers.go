@@ -0,0 +1,213 @@
+package v2
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	pb_auth_model "notes-rew/api/gen/go/auth_service/model/v2"
+	pb_auth_service "notes-rew/api/gen/go/auth_service/service/v2"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/usecase"
+)
+
+const (
+	userIDKey      = "userID"
+	accessTokenKey = "accessToken"
+)
+
+type AuthUsecase interface {
+	AuthenticateUser(ctx context.Context, req usecase.AuthInput) (*models.AuthResponse, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
+	Logout(ctx context.Context, userID uuid.UUID, accessToken, refreshToken string) error
+	LogoutEverywhere(ctx context.Context, userID uuid.UUID) error
+	EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*models.AuthResponse, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+type AuthServer struct {
+	usecase   AuthUsecase
+	validator *validator.Validate
+	pb_auth_service.UnimplementedAuthServiceServer
+}
+
+func (s *AuthServer) Login(ctx context.Context, req *pb_auth_model.LoginRequest) (*pb_auth_model.TokenPair, error) {
+	input := NewLoginInput(req)
+
+	if err := s.validator.Struct(input); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		return nil, status.Error(codes.InvalidArgument, "invalid argument")
+	}
+
+	authData, err := s.usecase.AuthenticateUser(ctx, input)
+	if err != nil {
+		if errors.Is(err, models.ErrEmailNotVerified) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		logrus.Error("password is not correct")
+		return nil, status.Error(codes.Unauthenticated, "password is not correct")
+	}
+
+	return NewTokenPair(authData), nil
+}
+
+func (s *AuthServer) Refresh(ctx context.Context, req *pb_auth_model.RefreshRequest) (*pb_auth_model.TokenPair, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	authData, err := s.usecase.RefreshTokens(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRefreshToken) || errors.Is(err, models.ErrRefreshTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logrus.Errorf("error refreshing tokens: %v", err)
+		return nil, status.Error(codes.Internal, "failed to refresh tokens")
+	}
+
+	return NewTokenPair(authData), nil
+}
+
+func (s *AuthServer) Logout(ctx context.Context, req *pb_auth_model.LogoutRequest) (*emptypb.Empty, error) {
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	accessToken, _ := ctx.Value(accessTokenKey).(string)
+
+	if err := s.usecase.Logout(ctx, currentUserID, accessToken, req.RefreshToken); err != nil {
+		logrus.Errorf("error logging out: %v", err)
+		return nil, status.Error(codes.Internal, "failed to log out")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AuthServer) LogoutAll(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	if err := s.usecase.LogoutEverywhere(ctx, currentUserID); err != nil {
+		logrus.Errorf("error logging out everywhere: %v", err)
+		return nil, status.Error(codes.Internal, "failed to log out")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *AuthServer) VerifyTwoFactor(
+	ctx context.Context,
+	req *pb_auth_model.VerifyTwoFactorRequest,
+) (*pb_auth_model.TokenPair, error) {
+	if req.ChallengeToken == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	authData, err := s.usecase.VerifyTwoFactor(ctx, req.ChallengeToken, req.Code)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidChallenge) || errors.Is(err, models.ErrInvalidTwoFactor) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logrus.Errorf("error verifying two-factor code: %v", err)
+		return nil, status.Error(codes.Internal, "failed to verify two-factor code")
+	}
+
+	return NewTokenPair(authData), nil
+}
+
+func (s *AuthServer) EnrollTwoFactor(ctx context.Context, _ *emptypb.Empty) (*pb_auth_model.TwoFactorEnrollment, error) {
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	enrollment, err := s.usecase.EnrollTwoFactor(ctx, currentUserID)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorEnabled) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.Errorf("error enrolling two-factor authentication: %v", err)
+		return nil, status.Error(codes.Internal, "failed to enroll two-factor authentication")
+	}
+
+	return NewTwoFactorEnrollment(enrollment), nil
+}
+
+func (s *AuthServer) ConfirmTwoFactor(
+	ctx context.Context,
+	req *pb_auth_model.TwoFactorCodeRequest,
+) (*pb_auth_model.RecoveryCodes, error) {
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	recoveryCodes, err := s.usecase.ConfirmTwoFactor(ctx, currentUserID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTwoFactor):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, models.ErrTwoFactorEnabled), errors.Is(err, models.ErrTwoFactorNotEnabled):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.Errorf("error confirming two-factor authentication: %v", err)
+		return nil, status.Error(codes.Internal, "failed to confirm two-factor authentication")
+	}
+
+	return &pb_auth_model.RecoveryCodes{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *AuthServer) DisableTwoFactor(ctx context.Context, req *pb_auth_model.TwoFactorCodeRequest) (*emptypb.Empty, error) {
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	if err := s.usecase.DisableTwoFactor(ctx, currentUserID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTwoFactor):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, models.ErrTwoFactorNotEnabled):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logrus.Errorf("error disabling two-factor authentication: %v", err)
+		return nil, status.Error(codes.Internal, "failed to disable two-factor authentication")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func NewAuthServer(
+	usecase AuthUsecase,
+	validator *validator.Validate,
+	unimplementedAuthServiceServer pb_auth_service.UnimplementedAuthServiceServer,
+) *AuthServer {
+	return &AuthServer{
+		usecase:                        usecase,
+		validator:                      validator,
+		UnimplementedAuthServiceServer: unimplementedAuthServiceServer,
+	}
+}
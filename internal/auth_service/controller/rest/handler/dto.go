@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"notes-rew/internal/auth_service/usecase"
 	"strings"
+	"time"
 )
 
 type SignUpResponse struct {
@@ -41,3 +42,55 @@ func (sir SignInRequest) ToDomain() usecase.AuthInput {
 		Password: sir.Password,
 	}
 }
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=254"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,security"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email,min=5,max=254"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=notes:read notes:write users:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r CreatePersonalTokenRequest) ToDomain() usecase.PersonalTokenInput {
+	return usecase.PersonalTokenInput{
+		Name:      strings.TrimSpace(r.Name),
+		Scopes:    r.Scopes,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
@@ -3,29 +3,64 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
 	"notes-rew/internal/auth_service/models"
 	"notes-rew/internal/auth_service/usecase"
+	"notes-rew/internal/middlewares"
+	"notes-rew/internal/token_manager"
 )
 
 type AuthUsecase interface {
 	CreateUser(ctx context.Context, req usecase.UserInput) (uuid.UUID, error)
 	AuthenticateUser(ctx context.Context, req usecase.AuthInput) (*models.AuthResponse, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
+	Logout(ctx context.Context, userID uuid.UUID, accessToken, refreshToken string) error
+	LogoutEverywhere(ctx context.Context, userID uuid.UUID) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*models.AuthResponse, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error
+	CreatePersonalToken(ctx context.Context, userID uuid.UUID, req usecase.PersonalTokenInput) (*models.CreatedPersonalToken, error)
+	ListPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error
 }
 
 type AuthController struct {
-	usecase   AuthUsecase
-	validator *validator.Validate
+	usecase      AuthUsecase
+	validator    *validator.Validate
+	tokenManager *token_manager.TokenManager
 }
 
 func (c *AuthController) Register(r chi.Router) {
+	r.Get("/.well-known/jwks.json", c.JWKSHandler)
+
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", c.SignUpHandler)
 		r.Post("/login", c.SignInHandler)
+		r.Post("/refresh", c.RefreshHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/logout", c.LogoutHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/logout/all", c.LogoutAllHandler)
+		r.Post("/password/forgot", c.ForgotPasswordHandler)
+		r.Post("/password/reset", c.ResetPasswordHandler)
+		r.Post("/verify-email", c.VerifyEmailHandler)
+		r.Post("/verify-email/resend", c.ResendVerificationHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/2fa/enroll", c.EnrollTwoFactorHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/2fa/confirm", c.ConfirmTwoFactorHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/2fa/disable", c.DisableTwoFactorHandler)
+		r.Post("/2fa/verify", c.VerifyTwoFactorHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Post("/tokens", c.CreatePersonalTokenHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Get("/tokens", c.GetPersonalTokensHandler)
+		r.With(middlewares.UserIdentity(c.tokenManager, middlewares.SessionOnly)).Delete("/tokens/{id}", c.RevokePersonalTokenHandler)
 	})
 }
 
@@ -78,7 +113,7 @@ func (c *AuthController) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 
 // SignInHandler
 // @Summary SignIn
-// @Description login user
+// @Description login user; with two-factor authentication the response only has two_factor_required and a challenge_token for /auth/2fa/verify
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -108,6 +143,10 @@ func (c *AuthController) SignInHandler(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := c.usecase.AuthenticateUser(ctx, domain)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailNotVerified) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "password is not correct", http.StatusInternalServerError)
 		return
 	}
@@ -120,9 +159,134 @@ func (c *AuthController) SignInHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func NewAuthController(usecase AuthUsecase, validator *validator.Validate) *AuthController {
+// RefreshHandler
+// @Summary Refresh
+// @Description exchange a refresh token for a new access and refresh token pair, the old refresh token stops working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body handler.RefreshRequest true "Refresh token"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /auth/refresh [post]
+func (c *AuthController) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.usecase.RefreshTokens(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRefreshToken) || errors.Is(err, models.ErrRefreshTokenReused) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		logrus.Errorf("error refreshing tokens: %v", err)
+		http.Error(w, "failed to refresh tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// LogoutHandler
+// @Summary Logout
+// @Description revoke the access token of the current session and, when given, its refresh token
+// @Security JWTAuth
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body handler.LogoutRequest false "Refresh token"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /auth/logout [post]
+func (c *AuthController) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, _ := ctx.Value(middlewares.TokenCtx).(string)
+
+	var req LogoutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.Logout(ctx, userID, accessToken, req.RefreshToken); err != nil {
+		logrus.Errorf("error logging out: %v", err)
+		http.Error(w, "failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAllHandler
+// @Summary LogoutAll
+// @Description revoke every access and refresh token of the current user
+// @Security JWTAuth
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 401
+// @Failure 500
+// @Router /auth/logout/all [post]
+func (c *AuthController) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.usecase.LogoutEverywhere(ctx, userID); err != nil {
+		logrus.Errorf("error logging out everywhere: %v", err)
+		http.Error(w, "failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewAuthController(
+	usecase AuthUsecase,
+	validator *validator.Validate,
+	tokenManager *token_manager.TokenManager,
+) *AuthController {
 	return &AuthController{
-		usecase:   usecase,
-		validator: validator,
+		usecase:      usecase,
+		validator:    validator,
+		tokenManager: tokenManager,
 	}
 }
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// jwksMaxAge lets verifiers cache the key set for a while. Keys are
+// published before they sign, so a cached set doesn't miss a rotation.
+const jwksMaxAge = "public, max-age=300"
+
+// JWKSHandler
+// @Summary JWKS
+// @Description public keys that verify access tokens, as a JSON Web Key Set; upcoming keys are listed before they start signing
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 500
+// @Router /.well-known/jwks.json [get]
+func (c *AuthController) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", jwksMaxAge)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(c.tokenManager.JWKS()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
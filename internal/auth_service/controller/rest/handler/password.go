@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+)
+
+// ForgotPasswordHandler
+// @Summary ForgotPassword
+// @Description mail a single-use, time-limited password reset link; the response is the same whether or not the email has an account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body handler.ForgotPasswordRequest true "Email of the account"
+// @Success 202
+// @Failure 400
+// @Failure 500
+// @Router /auth/password/forgot [post]
+func (c *AuthController) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.ForgotPassword(ctx, strings.ToLower(req.Email)); err != nil {
+		logrus.Errorf("error sending password reset: %v", err)
+		http.Error(w, "failed to send password reset", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPasswordHandler
+// @Summary ResetPassword
+// @Description set a new password with a reset token and end every session of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body handler.ResetPasswordRequest true "Reset token and new password"
+// @Success 204
+// @Failure 400
+// @Failure 500
+// @Router /auth/password/reset [post]
+func (c *AuthController) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		if errors.Is(err, models.ErrInvalidResetToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logrus.Errorf("error resetting password: %v", err)
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/middlewares"
+)
+
+// CreatePersonalTokenHandler
+// @Summary CreatePersonalToken
+// @Description create a named personal access token limited to the given scopes (notes:read, notes:write, users:read), optionally expiring; the token is only returned this once
+// @Security JWTAuth
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body handler.CreatePersonalTokenRequest true "Token name, scopes and expiry"
+// @Success 201
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /auth/tokens [post]
+func (c *AuthController) CreatePersonalTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreatePersonalTokenRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := c.usecase.CreatePersonalToken(ctx, userID, req.ToDomain())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTokenExpiryInPast):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrTokenNameTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logrus.Errorf("error creating personal access token: %v", err)
+			http.Error(w, "failed to create personal access token", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetPersonalTokensHandler
+// @Summary GetPersonalTokens
+// @Description list the personal access tokens of the current user with their scopes, expiry and last use
+// @Security JWTAuth
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 401
+// @Failure 500
+// @Router /auth/tokens [get]
+func (c *AuthController) GetPersonalTokensHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := c.usecase.ListPersonalTokens(ctx, userID)
+	if err != nil {
+		logrus.Errorf("error listing personal access tokens: %v", err)
+		http.Error(w, "failed to list personal access tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(tokens); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokePersonalTokenHandler
+// @Summary RevokePersonalToken
+// @Description revoke a personal access token of the current user
+// @Security JWTAuth
+// @Tags auth
+// @Param id path string true "Token ID"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 404
+// @Failure 500
+// @Router /auth/tokens/{id} [delete]
+func (c *AuthController) RevokePersonalTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid token id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RevokePersonalToken(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, models.ErrTokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logrus.Errorf("error revoking personal access token: %v", err)
+		http.Error(w, "failed to revoke personal access token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/middlewares"
+)
+
+// EnrollTwoFactorHandler
+// @Summary EnrollTwoFactor
+// @Description create an authenticator secret, returned with its otpauth provisioning URI and as a base64 PNG QR code; confirm it to enable two-factor authentication
+// @Security JWTAuth
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /auth/2fa/enroll [post]
+func (c *AuthController) EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := c.usecase.EnrollTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		logrus.Errorf("error enrolling two-factor authentication: %v", err)
+		http.Error(w, "failed to enroll two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(enrollment); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ConfirmTwoFactorHandler
+// @Summary ConfirmTwoFactor
+// @Description enable two-factor authentication with a code of the enrolled secret; the response holds the recovery codes, which are shown only this once
+// @Security JWTAuth
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body handler.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /auth/2fa/confirm [post]
+func (c *AuthController) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	var req TwoFactorCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := c.usecase.ConfirmTwoFactor(ctx, userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTwoFactor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrTwoFactorEnabled), errors.Is(err, models.ErrTwoFactorNotEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logrus.Errorf("error confirming two-factor authentication: %v", err)
+			http.Error(w, "failed to confirm two-factor authentication", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// VerifyTwoFactorHandler
+// @Summary VerifyTwoFactor
+// @Description complete a login with the challenge token it returned and an authenticator or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param challenge body handler.VerifyTwoFactorRequest true "Challenge token and code"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /auth/2fa/verify [post]
+func (c *AuthController) VerifyTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req VerifyTwoFactorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.usecase.VerifyTwoFactor(ctx, req.ChallengeToken, req.Code)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidChallenge) || errors.Is(err, models.ErrInvalidTwoFactor) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		logrus.Errorf("error verifying two-factor code: %v", err)
+		http.Error(w, "failed to verify two-factor code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DisableTwoFactorHandler
+// @Summary DisableTwoFactor
+// @Description turn two-factor authentication off with an authenticator or recovery code
+// @Security JWTAuth
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body handler.TwoFactorCodeRequest true "Authenticator or recovery code"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /auth/2fa/disable [post]
+func (c *AuthController) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middlewares.UserCtx).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusUnauthorized)
+		return
+	}
+
+	var req TwoFactorCodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.DisableTwoFactor(ctx, userID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTwoFactor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrTwoFactorNotEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logrus.Errorf("error disabling two-factor authentication: %v", err)
+			http.Error(w, "failed to disable two-factor authentication", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
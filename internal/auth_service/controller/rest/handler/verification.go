@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+)
+
+// VerifyEmailHandler
+// @Summary VerifyEmail
+// @Description confirm the email address of an account with the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body handler.VerifyEmailRequest true "Verification token"
+// @Success 204
+// @Failure 400
+// @Failure 500
+// @Router /auth/verify-email [post]
+func (c *AuthController) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req VerifyEmailRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.VerifyEmail(ctx, req.Token); err != nil {
+		if errors.Is(err, models.ErrInvalidVerifyToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logrus.Errorf("error verifying email: %v", err)
+		http.Error(w, "failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerificationHandler
+// @Summary ResendVerification
+// @Description mail a new verification link; the response is the same whether or not the email has an unverified account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body handler.ResendVerificationRequest true "Email of the account"
+// @Success 202
+// @Failure 400
+// @Failure 500
+// @Router /auth/verify-email/resend [post]
+func (c *AuthController) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ResendVerificationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Errorf("error decoding request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.usecase.ResendVerification(ctx, strings.ToLower(req.Email)); err != nil {
+		logrus.Errorf("error resending verification: %v", err)
+		http.Error(w, "failed to send verification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
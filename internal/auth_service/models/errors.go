@@ -0,0 +1,19 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidResetToken   = errors.New("password reset token is invalid or expired")
+	ErrInvalidVerifyToken  = errors.New("email verification token is invalid or expired")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactor    = errors.New("two-factor code is invalid")
+	ErrInvalidChallenge    = errors.New("two-factor challenge is invalid or expired")
+	ErrTokenNotFound       = errors.New("personal access token not found")
+	ErrTokenNameTaken      = errors.New("a personal access token with this name already exists")
+	ErrTokenExpiryInPast   = errors.New("personal access token expiry must be in the future")
+)
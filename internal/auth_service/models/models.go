@@ -1,14 +1,72 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type AuthOutput struct {
 	UserID       uuid.UUID `json:"user_id"`
 	Username     string    `json:"username"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"passwordHash"`
+	// EmailVerifiedAt is nil until the user follows the link mailed to
+	// their address.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
+// AuthResponse is the result of a login. For users with two-factor
+// authentication it carries a ChallengeToken instead of the tokens, and
+// ExpiresAt is when the challenge expires.
 type AuthResponse struct {
+	Token             string    `json:"token,omitempty"`
+	RefreshToken      string    `json:"refresh_token,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty"`
+	ChallengeToken    string    `json:"challenge_token,omitempty"`
+}
+
+// RefreshToken is a stored refresh token. Every token issued by rotating
+// another one shares its FamilyID with it.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// TOTP is the authenticator secret of a user. Two-factor authentication is
+// enabled once ConfirmedAt is set.
+type TOTP struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+// TwoFactorEnrollment is what authenticator apps need to add the account:
+// the secret, the otpauth URI and that URI as a PNG QR code.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          []byte `json:"qr_code"`
+}
+
+// PersonalToken describes a personal access token. The token itself is only
+// known when it is created.
+type PersonalToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatedPersonalToken struct {
+	PersonalToken
 	Token string `json:"token"`
 }
@@ -18,3 +18,44 @@ type SignInInput struct {
 	Email    string
 	Password string
 }
+
+type CreateRefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type CreatePasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type CreateTOTP struct {
+	UserID    uuid.UUID
+	Secret    string
+	CreatedAt time.Time
+}
+
+type CreateTwoFactorChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type CreatePersonalToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	TokenHash string
+	Scopes    []string
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
@@ -2,13 +2,40 @@ package service
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"notes-rew/internal/auth_service/models"
+	"time"
 )
 
 type AuthStorage interface {
 	SaveUserToDB(ctx context.Context, user CreateUser) error
 	GetUserForAuth(ctx context.Context, email string) (models.AuthOutput, error)
 	CheckUserByEmail(ctx context.Context, email string) error
+	SaveRefreshToken(ctx context.Context, token CreateRefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	RevokeTokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error
+	SavePasswordResetToken(ctx context.Context, token CreatePasswordResetToken) error
+	UsePasswordResetToken(ctx context.Context, tokenHash string, usedAt time.Time) (uuid.UUID, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID, usedAt time.Time) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, updatedAt time.Time) error
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error
+	GetUserByID(ctx context.Context, id uuid.UUID) (models.AuthOutput, error)
+	GetTOTP(ctx context.Context, userID uuid.UUID) (models.TOTP, error)
+	SaveTOTP(ctx context.Context, totp CreateTOTP) error
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, confirmedAt time.Time) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteTOTP(ctx context.Context, userID uuid.UUID) error
+	SaveRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string, createdAt time.Time) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	SaveTwoFactorChallenge(ctx context.Context, challenge CreateTwoFactorChallenge) error
+	AttemptTwoFactorChallenge(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (uuid.UUID, error)
+	UseTwoFactorChallenge(ctx context.Context, tokenHash string, usedAt time.Time) error
+	SavePersonalToken(ctx context.Context, token CreatePersonalToken) error
+	GetPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error)
+	DeletePersonalToken(ctx context.Context, userID, id uuid.UUID) error
 }
 
 type AuthService struct {
@@ -27,6 +54,106 @@ func (s *AuthService) AuthByEmail(ctx context.Context, req SignInInput) (models.
 	return s.storage.GetUserForAuth(ctx, req.Email)
 }
 
+func (s *AuthService) SaveRefreshToken(ctx context.Context, token CreateRefreshToken) error {
+	return s.storage.SaveRefreshToken(ctx, token)
+}
+
+func (s *AuthService) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	return s.storage.GetRefreshToken(ctx, tokenHash)
+}
+
+func (s *AuthService) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return s.storage.MarkRefreshTokenUsed(ctx, id, usedAt)
+}
+
+func (s *AuthService) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error {
+	return s.storage.RevokeTokenFamily(ctx, familyID, revokedAt)
+}
+
+func (s *AuthService) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error {
+	return s.storage.RevokeUserRefreshTokens(ctx, userID, revokedAt)
+}
+
+func (s *AuthService) SavePasswordResetToken(ctx context.Context, token CreatePasswordResetToken) error {
+	return s.storage.SavePasswordResetToken(ctx, token)
+}
+
+func (s *AuthService) UsePasswordResetToken(ctx context.Context, tokenHash string, usedAt time.Time) (uuid.UUID, error) {
+	return s.storage.UsePasswordResetToken(ctx, tokenHash, usedAt)
+}
+
+func (s *AuthService) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID, usedAt time.Time) error {
+	return s.storage.InvalidatePasswordResetTokens(ctx, userID, usedAt)
+}
+
+func (s *AuthService) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, updatedAt time.Time) error {
+	return s.storage.UpdatePassword(ctx, userID, passwordHash, updatedAt)
+}
+
+func (s *AuthService) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error {
+	return s.storage.MarkEmailVerified(ctx, userID, email, verifiedAt)
+}
+
+func (s *AuthService) GetUserByID(ctx context.Context, id uuid.UUID) (models.AuthOutput, error) {
+	return s.storage.GetUserByID(ctx, id)
+}
+
+func (s *AuthService) GetTOTP(ctx context.Context, userID uuid.UUID) (models.TOTP, error) {
+	return s.storage.GetTOTP(ctx, userID)
+}
+
+func (s *AuthService) SaveTOTP(ctx context.Context, totp CreateTOTP) error {
+	return s.storage.SaveTOTP(ctx, totp)
+}
+
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, confirmedAt time.Time) error {
+	return s.storage.ConfirmTOTP(ctx, userID, step, confirmedAt)
+}
+
+func (s *AuthService) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	return s.storage.UseTOTPStep(ctx, userID, step)
+}
+
+func (s *AuthService) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	return s.storage.DeleteTOTP(ctx, userID)
+}
+
+func (s *AuthService) SaveRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string, createdAt time.Time) error {
+	return s.storage.SaveRecoveryCodes(ctx, userID, codeHashes, createdAt)
+}
+
+func (s *AuthService) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error {
+	return s.storage.UseRecoveryCode(ctx, userID, codeHash, usedAt)
+}
+
+func (s *AuthService) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return s.storage.DeleteRecoveryCodes(ctx, userID)
+}
+
+func (s *AuthService) SaveTwoFactorChallenge(ctx context.Context, challenge CreateTwoFactorChallenge) error {
+	return s.storage.SaveTwoFactorChallenge(ctx, challenge)
+}
+
+func (s *AuthService) AttemptTwoFactorChallenge(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (uuid.UUID, error) {
+	return s.storage.AttemptTwoFactorChallenge(ctx, tokenHash, maxAttempts, now)
+}
+
+func (s *AuthService) UseTwoFactorChallenge(ctx context.Context, tokenHash string, usedAt time.Time) error {
+	return s.storage.UseTwoFactorChallenge(ctx, tokenHash, usedAt)
+}
+
+func (s *AuthService) SavePersonalToken(ctx context.Context, token CreatePersonalToken) error {
+	return s.storage.SavePersonalToken(ctx, token)
+}
+
+func (s *AuthService) GetPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error) {
+	return s.storage.GetPersonalTokens(ctx, userID)
+}
+
+func (s *AuthService) DeletePersonalToken(ctx context.Context, userID, id uuid.UUID) error {
+	return s.storage.DeletePersonalToken(ctx, userID, id)
+}
+
 func NewAuthService(storage AuthStorage) *AuthService {
 	return &AuthService{storage: storage}
 }
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+)
+
+func (s *UserStorage) SavePasswordResetToken(ctx context.Context, token service.CreatePasswordResetToken) error {
+	sql, args, err := squirrel.Insert("password_reset_tokens").
+		Columns("id", "user_id", "token_hash", "expires_at", "created_at").
+		Values(token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UsePasswordResetToken spends the token and returns the user it was issued
+// to. Only one of several concurrent calls for the same token succeeds; the
+// others, like calls with a spent or expired token, get ErrInvalidResetToken.
+func (s *UserStorage) UsePasswordResetToken(ctx context.Context, tokenHash string, usedAt time.Time) (uuid.UUID, error) {
+	sql, args, err := squirrel.Update("password_reset_tokens").
+		Set("used_at", usedAt).
+		Where(squirrel.Eq{"token_hash": tokenHash, "used_at": nil}).
+		Where(squirrel.Gt{"expires_at": usedAt}).
+		Suffix("RETURNING user_id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var userID uuid.UUID
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, models.ErrInvalidResetToken
+		}
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// InvalidatePasswordResetTokens spends every outstanding token of the user.
+func (s *UserStorage) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID, usedAt time.Time) error {
+	sql, args, err := squirrel.Update("password_reset_tokens").
+		Set("used_at", usedAt).
+		Where(squirrel.Eq{"user_id": userID, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStorage) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, updatedAt time.Time) error {
+	sql, args, err := squirrel.Update("users").
+		Set("password", passwordHash).
+		Set("updated_at", updatedAt).
+		Where(squirrel.Eq{"id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+)
+
+const uniqueViolation = "23505"
+
+func (s *UserStorage) SavePersonalToken(ctx context.Context, token service.CreatePersonalToken) error {
+	sql, args, err := squirrel.Insert("personal_access_tokens").
+		Columns("id", "user_id", "name", "token_hash", "scopes", "expires_at", "created_at").
+		Values(token.ID, token.UserID, token.Name, token.TokenHash, token.Scopes, token.ExpiresAt, token.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.ErrTokenNameTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetPersonalTokens returns the user's personal access tokens, newest first.
+func (s *UserStorage) GetPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error) {
+	sql, args, err := squirrel.Select("id", "name", "scopes", "expires_at", "last_used_at", "created_at").
+		From("personal_access_tokens").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at DESC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]models.PersonalToken, 0)
+
+	for rows.Next() {
+		var token models.PersonalToken
+
+		err = rows.Scan(&token.ID, &token.Name, &token.Scopes, &token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *UserStorage) DeletePersonalToken(ctx context.Context, userID, id uuid.UUID) error {
+	sql, args, err := squirrel.Delete("personal_access_tokens").
+		Where(squirrel.Eq{"id": id, "user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrTokenNotFound
+	}
+
+	return nil
+}
@@ -3,16 +3,25 @@ package postgres
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"notes-rew/internal/auth_service/models"
 	"notes-rew/internal/auth_service/service"
 	"notes-rew/internal/auth_service/storage"
+	"notes-rew/internal/db/transactor"
 )
 
 type UserStorage struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
+}
+
+// conn returns the transaction carried by ctx, if any, or the pool.
+func (s *UserStorage) conn(ctx context.Context) transactor.Querier {
+	return transactor.Conn(ctx, s.db)
 }
 
 func (s *UserStorage) SaveUserToDB(ctx context.Context, user service.CreateUser) error {
@@ -24,7 +33,7 @@ func (s *UserStorage) SaveUserToDB(ctx context.Context, user service.CreateUser)
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
@@ -35,7 +44,9 @@ func (s *UserStorage) SaveUserToDB(ctx context.Context, user service.CreateUser)
 func (s *UserStorage) GetUserForAuth(ctx context.Context, email string) (models.AuthOutput, error) {
 	var user storage.AuthResponse
 
-	sql, args, err := squirrel.Select("id", "username", "email", "password").
+	var verifiedAt *time.Time
+
+	sql, args, err := squirrel.Select("id", "username", "email", "password", "email_verified_at").
 		From("users").
 		Where(squirrel.Eq{"email": email}).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
@@ -44,12 +55,44 @@ func (s *UserStorage) GetUserForAuth(ctx context.Context, email string) (models.
 		return models.AuthOutput{}, err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &verifiedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AuthOutput{}, models.ErrUserNotFound
+		}
+		return models.AuthOutput{}, err
+	}
+
+	resp := storage.NewAuthResponse(user.ID, user.Username, user.Email, user.PasswordHash)
+	resp.EmailVerifiedAt = verifiedAt
+
+	return resp, nil
+}
+
+func (s *UserStorage) GetUserByID(ctx context.Context, id uuid.UUID) (models.AuthOutput, error) {
+	var user storage.AuthResponse
+
+	var verifiedAt *time.Time
+
+	sql, args, err := squirrel.Select("id", "username", "email", "password", "email_verified_at").
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.AuthOutput{}, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &verifiedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AuthOutput{}, models.ErrUserNotFound
+		}
 		return models.AuthOutput{}, err
 	}
 
 	resp := storage.NewAuthResponse(user.ID, user.Username, user.Email, user.PasswordHash)
+	resp.EmailVerifiedAt = verifiedAt
 
 	return resp, nil
 }
@@ -65,7 +108,7 @@ func (s *UserStorage) CheckUserByEmail(ctx context.Context, email string) error
 		return err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&count)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -77,6 +120,29 @@ func (s *UserStorage) CheckUserByEmail(ctx context.Context, email string) error
 	return nil
 }
 
-func NewUserStorage(db *pgx.Conn) *UserStorage {
+// MarkEmailVerified records that the user confirmed the address. It fails
+// with ErrInvalidVerifyToken when the user no longer has that address.
+func (s *UserStorage) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error {
+	sql, args, err := squirrel.Update("users").
+		Set("email_verified_at", squirrel.Expr("coalesce(email_verified_at, ?)", verifiedAt)).
+		Where(squirrel.Eq{"id": userID, "email": email}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidVerifyToken
+	}
+
+	return nil
+}
+
+func NewUserStorage(db *pgxpool.Pool) *UserStorage {
 	return &UserStorage{db: db}
 }
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+)
+
+func (s *UserStorage) SaveRefreshToken(ctx context.Context, token service.CreateRefreshToken) error {
+	sql, args, err := squirrel.Insert("refresh_tokens").
+		Columns("id", "user_id", "family_id", "token_hash", "expires_at", "created_at").
+		Values(token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStorage) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	sql, args, err := squirrel.Select("id", "user_id", "family_id", "expires_at", "used_at", "revoked_at").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RefreshToken{}, models.ErrInvalidRefreshToken
+		}
+		return models.RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+// MarkRefreshTokenUsed flags the token as spent. Only one of several
+// concurrent calls for the same token succeeds, the others get
+// ErrRefreshTokenReused.
+func (s *UserStorage) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	sql, args, err := squirrel.Update("refresh_tokens").
+		Set("used_at", usedAt).
+		Where(squirrel.Eq{"id": id, "used_at": nil, "revoked_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (s *UserStorage) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error {
+	sql, args, err := squirrel.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where(squirrel.Eq{"family_id": familyID, "revoked_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStorage) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error {
+	sql, args, err := squirrel.Update("refresh_tokens").
+		Set("revoked_at", revokedAt).
+		Where(squirrel.Eq{"user_id": userID, "revoked_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+)
+
+func (s *UserStorage) GetTOTP(ctx context.Context, userID uuid.UUID) (models.TOTP, error) {
+	sql, args, err := squirrel.Select("user_id", "secret", "confirmed_at", "last_used_step").
+		From("user_totp").
+		Where(squirrel.Eq{"user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return models.TOTP{}, err
+	}
+
+	var totp models.TOTP
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&totp.UserID, &totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, models.ErrTwoFactorNotEnabled
+		}
+		return models.TOTP{}, err
+	}
+
+	return totp, nil
+}
+
+// SaveTOTP stores a new secret for the user, replacing one that was never
+// confirmed. It fails with ErrTwoFactorEnabled when the user already
+// confirmed theirs.
+func (s *UserStorage) SaveTOTP(ctx context.Context, totp service.CreateTOTP) error {
+	sql, args, err := squirrel.Insert("user_totp").
+		Columns("user_id", "secret", "created_at").
+		Values(totp.UserID, totp.Secret, totp.CreatedAt).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, " +
+			"created_at = excluded.created_at, last_used_step = 0 " +
+			"WHERE user_totp.confirmed_at IS NULL").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// ConfirmTOTP enables two-factor authentication with the secret the user
+// proved to have, step being that of the code they confirmed it with.
+func (s *UserStorage) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, confirmedAt time.Time) error {
+	sql, args, err := squirrel.Update("user_totp").
+		Set("confirmed_at", confirmedAt).
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID, "confirmed_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that the code of step was accepted. It fails with
+// ErrInvalidTwoFactor for a step that is not newer than the last one used,
+// so that a code can't be replayed, not even by concurrent calls.
+func (s *UserStorage) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	sql, args, err := squirrel.Update("user_totp").
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Lt{"last_used_step": step}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidTwoFactor
+	}
+
+	return nil
+}
+
+func (s *UserStorage) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := squirrel.Delete("user_totp").
+		Where(squirrel.Eq{"user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStorage) SaveRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string, createdAt time.Time) error {
+	query := squirrel.Insert("totp_recovery_codes").
+		Columns("id", "user_id", "code_hash", "created_at")
+
+	for _, codeHash := range codeHashes {
+		query = query.Values(uuid.New(), userID, codeHash, createdAt)
+	}
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UseRecoveryCode spends one of the user's recovery codes. It fails with
+// ErrInvalidTwoFactor for unknown and spent codes.
+func (s *UserStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error {
+	sql, args, err := squirrel.Update("totp_recovery_codes").
+		Set("used_at", usedAt).
+		Where(squirrel.Eq{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidTwoFactor
+	}
+
+	return nil
+}
+
+func (s *UserStorage) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := squirrel.Delete("totp_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStorage) SaveTwoFactorChallenge(ctx context.Context, challenge service.CreateTwoFactorChallenge) error {
+	sql, args, err := squirrel.Insert("two_factor_challenges").
+		Columns("id", "user_id", "token_hash", "expires_at", "created_at").
+		Values(challenge.ID, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt, challenge.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AttemptTwoFactorChallenge counts an attempt to answer the challenge and
+// returns the user it was issued to. It fails with ErrInvalidChallenge for
+// spent and expired challenges and once maxAttempts have been made.
+func (s *UserStorage) AttemptTwoFactorChallenge(
+	ctx context.Context,
+	tokenHash string,
+	maxAttempts int,
+	now time.Time,
+) (uuid.UUID, error) {
+	sql, args, err := squirrel.Update("two_factor_challenges").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Where(squirrel.Eq{"token_hash": tokenHash, "used_at": nil}).
+		Where(squirrel.Gt{"expires_at": now}).
+		Where(squirrel.Lt{"attempts": maxAttempts}).
+		Suffix("RETURNING user_id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var userID uuid.UUID
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, models.ErrInvalidChallenge
+		}
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// UseTwoFactorChallenge spends the challenge. Only one of several
+// concurrent calls succeeds; the others get ErrInvalidChallenge.
+func (s *UserStorage) UseTwoFactorChallenge(ctx context.Context, tokenHash string, usedAt time.Time) error {
+	sql, args, err := squirrel.Update("two_factor_challenges").
+		Set("used_at", usedAt).
+		Where(squirrel.Eq{"token_hash": tokenHash, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrInvalidChallenge
+	}
+
+	return nil
+}
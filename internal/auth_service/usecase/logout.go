@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/auth_service/models"
+)
+
+// Logout ends the current session: the access token is revoked right away
+// and, when given, the refresh token stops working along with every token
+// rotated from the same login.
+func (u *AuthUsecase) Logout(ctx context.Context, userID uuid.UUID, accessToken, refreshToken string) error {
+	claims, err := u.tokenManager.ParseClaims(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err = u.tokenManager.Revoke(ctx, claims); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := u.service.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRefreshToken) {
+			return nil
+		}
+		return err
+	}
+
+	if token.UserID != userID {
+		return nil
+	}
+
+	return u.service.RevokeTokenFamily(ctx, token.FamilyID, time.Now().UTC())
+}
+
+// LogoutEverywhere revokes every access and refresh token issued to the user.
+func (u *AuthUsecase) LogoutEverywhere(ctx context.Context, userID uuid.UUID) error {
+	if err := u.tokenManager.RevokeAll(ctx, userID.String()); err != nil {
+		return err
+	}
+
+	return u.service.RevokeUserRefreshTokens(ctx, userID, time.Now().UTC())
+}
@@ -29,8 +29,39 @@ func NewUserOutput(username, email, passwordHash string) service.CreateUser {
 	}
 }
 
-func NewAuthResponse(token string) *models.AuthResponse {
+func NewAuthResponse(token, refreshToken string, expiresAt time.Time) *models.AuthResponse {
 	return &models.AuthResponse{
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
 	}
 }
+
+// AccountSettings configure the links mailed to users, each being a client
+// page the token is appended to and how long the token stays valid, whether
+// unverified accounts may log in and two-factor authentication.
+type AccountSettings struct {
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+	VerifyEmailURL   string
+	VerifyEmailTTL   time.Duration
+	// VerificationSecret signs the verification tokens, which are not
+	// stored.
+	VerificationSecret []byte
+	// RequireVerifiedEmail makes AuthenticateUser refuse unverified
+	// accounts.
+	RequireVerifiedEmail bool
+	// TwoFactorIssuer names the service in authenticator apps.
+	TwoFactorIssuer string
+	// TwoFactorChallengeTTL is how long the second step of a login may
+	// take.
+	TwoFactorChallengeTTL time.Duration
+}
+
+// PersonalTokenInput describes a new personal access token. Without
+// ExpiresAt it works until revoked.
+type PersonalTokenInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+	"notes-rew/internal/mailer"
+)
+
+// ForgotPassword mails the user a link to set a new password. Only the
+// latest link works, once, and only for a limited time. An unknown email is
+// not an error, so that the endpoint doesn't reveal who has an account.
+func (u *AuthUsecase) ForgotPassword(ctx context.Context, email string) error {
+	user, err := u.service.AuthByEmail(ctx, service.SignInInput{Email: email})
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			logrus.Printf("password reset requested for unknown email")
+			return nil
+		}
+		return err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.InvalidatePasswordResetTokens(ctx, user.UserID, now); err != nil {
+			return err
+		}
+
+		return u.service.SavePasswordResetToken(ctx, service.CreatePasswordResetToken{
+			ID:        uuid.New(),
+			UserID:    user.UserID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(u.account.PasswordResetTTL),
+			CreatedAt: now,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nFollow this link to set a new password:\n\n%s\n\n"+
+				"The link works once and expires in %s. If you didn't ask for it, ignore this email.\n",
+			user.Username, linkWithToken(u.account.PasswordResetURL, token), u.account.PasswordResetTTL,
+		),
+	})
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and
+// ends every session of the user, as LogoutEverywhere does.
+func (u *AuthUsecase) ResetPassword(ctx context.Context, token, password string) error {
+	hashedPassword, err := u.hasher.HasherPassword(password)
+	if err != nil {
+		logrus.Printf("hash password error: %s", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	var userID uuid.UUID
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		userID, err = u.service.UsePasswordResetToken(ctx, hashToken(token), now)
+		if err != nil {
+			return err
+		}
+
+		if err = u.service.UpdatePassword(ctx, userID, hashedPassword, now); err != nil {
+			return err
+		}
+
+		if err = u.service.InvalidatePasswordResetTokens(ctx, userID, now); err != nil {
+			return err
+		}
+
+		return u.service.RevokeUserRefreshTokens(ctx, userID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The password is changed by now, so a failure here is only logged:
+	// the access tokens still expire on their own shortly.
+	if err = u.tokenManager.RevokeAll(ctx, userID.String()); err != nil {
+		logrus.Printf("error revoking access tokens of user %s after password reset: %s", userID, err)
+	}
+
+	return nil
+}
+
+// newToken returns an opaque random token for links sent by email.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func linkWithToken(base, token string) string {
+	link, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+	"notes-rew/internal/token_manager"
+)
+
+// CreatePersonalToken issues a personal access token for scripts and
+// integrations. The token is returned this once; only its hash is kept.
+func (u *AuthUsecase) CreatePersonalToken(
+	ctx context.Context,
+	userID uuid.UUID,
+	req PersonalTokenInput,
+) (*models.CreatedPersonalToken, error) {
+	now := time.Now().UTC()
+
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		if !expiresAt.After(now) {
+			return nil, models.ErrTokenExpiryInPast
+		}
+		req.ExpiresAt = &expiresAt
+	}
+
+	secret, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := token_manager.PersonalTokenPrefix + secret
+	scopes := uniqueScopes(req.Scopes)
+
+	create := service.CreatePersonalToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: token_manager.HashPersonalToken(token),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	if err = u.service.SavePersonalToken(ctx, create); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedPersonalToken{
+		PersonalToken: models.PersonalToken{
+			ID:        create.ID,
+			Name:      create.Name,
+			Scopes:    scopes,
+			ExpiresAt: create.ExpiresAt,
+			CreatedAt: now,
+		},
+		Token: token,
+	}, nil
+}
+
+func (u *AuthUsecase) ListPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error) {
+	return u.service.GetPersonalTokens(ctx, userID)
+}
+
+// RevokePersonalToken deletes the token, which stops working at once.
+func (u *AuthUsecase) RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	return u.service.DeletePersonalToken(ctx, userID, tokenID)
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+)
+
+// RefreshTokens exchanges a refresh token for a new access and refresh token
+// pair. Each refresh token can be used once: presenting a spent token means
+// it leaked, so its whole family is revoked and the user has to log in again.
+func (u *AuthUsecase) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	token, err := u.service.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	if token.RevokedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		return nil, u.revokeFamily(ctx, token, now)
+	}
+
+	// Spending the old token and saving its successor happen together, so a
+	// failure in between can't lock the user out. The family is revoked
+	// outside of the transaction to survive its rollback.
+	var resp *models.AuthResponse
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.MarkRefreshTokenUsed(ctx, token.ID, now); err != nil {
+			return err
+		}
+
+		resp, err = u.issueTokens(ctx, token.UserID, token.FamilyID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			return nil, u.revokeFamily(ctx, token, now)
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (u *AuthUsecase) revokeFamily(ctx context.Context, token models.RefreshToken, now time.Time) error {
+	logrus.Printf("refresh token reuse detected, revoking family %s of user %s", token.FamilyID, token.UserID)
+
+	if err := u.service.RevokeTokenFamily(ctx, token.FamilyID, now); err != nil {
+		return err
+	}
+
+	return models.ErrRefreshTokenReused
+}
+
+func (u *AuthUsecase) issueTokens(ctx context.Context, userID, familyID uuid.UUID) (*models.AuthResponse, error) {
+	now := time.Now().UTC()
+
+	jwt, err := u.tokenManager.NewJWT(ctx, userID.String())
+	if err != nil {
+		logrus.Printf("jwt error: %s", err)
+		return nil, err
+	}
+
+	refreshToken, err := u.tokenManager.NewRefreshToken()
+	if err != nil {
+		logrus.Printf("refresh token error: %s", err)
+		return nil, err
+	}
+
+	err = u.service.SaveRefreshToken(ctx, service.CreateRefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(u.tokenManager.RefreshTokenTTL()),
+		CreatedAt: now,
+	})
+	if err != nil {
+		logrus.Printf("save refresh token error: %s", err)
+		return nil, err
+	}
+
+	return NewAuthResponse(jwt, refreshToken, now.Add(u.tokenManager.AccessTokenTTL())), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
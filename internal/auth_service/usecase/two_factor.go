@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+	"notes-rew/internal/totp"
+)
+
+const (
+	// RecoveryCodeCount is how many recovery codes a user gets when
+	// enabling two-factor authentication.
+	RecoveryCodeCount = 10
+	// maxChallengeAttempts is how many codes can be tried against one
+	// challenge before the user has to log in again.
+	maxChallengeAttempts = 5
+
+	qrCodeSize = 256
+)
+
+// EnrollTwoFactor creates a new authenticator secret for the user. It only
+// takes effect once confirmed with ConfirmTwoFactor; enrolling again before
+// that replaces the secret.
+func (u *AuthUsecase) EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*models.TwoFactorEnrollment, error) {
+	user, err := u.service.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.service.SaveTOTP(ctx, service.CreateTOTP{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uri := totp.ProvisioningURI(u.account.TwoFactorIssuer, user.Email, secret)
+
+	qrCode, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          qrCode,
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user shows a
+// code of the enrolled secret, and returns their recovery codes. They are
+// only stored hashed, so this is the one time they can be shown.
+func (u *AuthUsecase) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	secret, err := u.service.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.ConfirmedAt != nil {
+		return nil, models.ErrTwoFactorEnabled
+	}
+
+	now := time.Now().UTC()
+
+	step, ok := totp.Validate(secret.Secret, normalizeCode(code), now)
+	if !ok {
+		return nil, models.ErrInvalidTwoFactor
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.ConfirmTOTP(ctx, userID, step, now); err != nil {
+			return err
+		}
+
+		if err := u.service.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+
+		return u.service.SaveRecoveryCodes(ctx, userID, hashes, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyTwoFactor completes a login with the challenge token returned by
+// AuthenticateUser and a code from the authenticator app or a recovery
+// code. Each challenge works once and allows a few attempts only.
+func (u *AuthUsecase) VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*models.AuthResponse, error) {
+	tokenHash := hashToken(challengeToken)
+	now := time.Now().UTC()
+
+	// Attempts are counted outside of the transaction so that wrong codes
+	// count too.
+	userID, err := u.service.AttemptTwoFactorChallenge(ctx, tokenHash, maxChallengeAttempts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *models.AuthResponse
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.checkSecondFactor(ctx, userID, code, now); err != nil {
+			return err
+		}
+
+		if err := u.service.UseTwoFactorChallenge(ctx, tokenHash, now); err != nil {
+			return err
+		}
+
+		resp, err = u.issueTokens(ctx, userID, uuid.New())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, which takes a
+// current code or a recovery code.
+func (u *AuthUsecase) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.checkSecondFactor(ctx, userID, code, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		if err := u.service.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+
+		return u.service.DeleteTOTP(ctx, userID)
+	})
+}
+
+// twoFactorEnabled reports whether the user confirmed an authenticator.
+func (u *AuthUsecase) twoFactorEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	secret, err := u.service.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrTwoFactorNotEnabled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return secret.ConfirmedAt != nil, nil
+}
+
+// newChallenge starts the second step of a login.
+func (u *AuthUsecase) newChallenge(ctx context.Context, userID uuid.UUID) (*models.AuthResponse, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(u.account.TwoFactorChallengeTTL)
+
+	err = u.service.SaveTwoFactorChallenge(ctx, service.CreateTwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		ExpiresAt:         expiresAt,
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+	}, nil
+}
+
+// checkSecondFactor accepts a code of the user's authenticator, unless it
+// was used before, or spends one of their recovery codes.
+func (u *AuthUsecase) checkSecondFactor(ctx context.Context, userID uuid.UUID, code string, now time.Time) error {
+	secret, err := u.service.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if secret.ConfirmedAt == nil {
+		return models.ErrTwoFactorNotEnabled
+	}
+
+	code = normalizeCode(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret.Secret, code, now)
+		if !ok {
+			return models.ErrInvalidTwoFactor
+		}
+
+		return u.service.UseTOTPStep(ctx, userID, step)
+	}
+
+	return u.service.UseRecoveryCode(ctx, userID, hashToken(code), now)
+}
+
+// newRecoveryCodes returns RecoveryCodeCount codes formatted for humans
+// along with the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	b := make([]byte, 5)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(b)
+
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode drops what people type around codes: spaces, the dash of
+// recovery codes and capitals.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -7,29 +7,58 @@ import (
 	"notes-rew/internal/auth_service/models"
 	"notes-rew/internal/auth_service/service"
 	"notes-rew/internal/hash"
+	"notes-rew/internal/mailer"
 	"notes-rew/internal/token_manager"
 	"strings"
+	"time"
 )
 
 type AuthService interface {
 	CreateUserServ(ctx context.Context, user service.CreateUser) error
 	AuthByEmail(ctx context.Context, req service.SignInInput) (models.AuthOutput, error)
 	CheckUserByEmail(ctx context.Context, email string) error
+	SaveRefreshToken(ctx context.Context, token service.CreateRefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	RevokeTokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error
+	SavePasswordResetToken(ctx context.Context, token service.CreatePasswordResetToken) error
+	UsePasswordResetToken(ctx context.Context, tokenHash string, usedAt time.Time) (uuid.UUID, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID, usedAt time.Time) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, updatedAt time.Time) error
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error
+	GetUserByID(ctx context.Context, id uuid.UUID) (models.AuthOutput, error)
+	GetTOTP(ctx context.Context, userID uuid.UUID) (models.TOTP, error)
+	SaveTOTP(ctx context.Context, totp service.CreateTOTP) error
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, confirmedAt time.Time) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteTOTP(ctx context.Context, userID uuid.UUID) error
+	SaveRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string, createdAt time.Time) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	SaveTwoFactorChallenge(ctx context.Context, challenge service.CreateTwoFactorChallenge) error
+	AttemptTwoFactorChallenge(ctx context.Context, tokenHash string, maxAttempts int, now time.Time) (uuid.UUID, error)
+	UseTwoFactorChallenge(ctx context.Context, tokenHash string, usedAt time.Time) error
+	SavePersonalToken(ctx context.Context, token service.CreatePersonalToken) error
+	GetPersonalTokens(ctx context.Context, userID uuid.UUID) ([]models.PersonalToken, error)
+	DeletePersonalToken(ctx context.Context, userID, id uuid.UUID) error
+}
+
+// Transactor runs fn in one database transaction carried by its context.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type AuthUsecase struct {
 	service      AuthService
 	hasher       hash.Hasher
 	tokenManager *token_manager.TokenManager
+	transactor   Transactor
+	mailer       mailer.Mailer
+	account      AccountSettings
 }
 
 func (u *AuthUsecase) CreateUser(ctx context.Context, req UserInput) (uuid.UUID, error) {
-	err := u.service.CheckUserByEmail(ctx, strings.ToLower(req.Email))
-	if err != nil {
-		logrus.Printf("check user error: %s", err)
-		return uuid.Nil, err
-	}
-
 	hashedPassword, err := u.hasher.HasherPassword(req.Password)
 	if err != nil {
 		logrus.Printf("hash password error: %s", err)
@@ -38,12 +67,28 @@ func (u *AuthUsecase) CreateUser(ctx context.Context, req UserInput) (uuid.UUID,
 
 	newUser := NewUserOutput(req.Username, req.Email, hashedPassword) // возвращает новый id
 
-	err = u.service.CreateUserServ(ctx, newUser)
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.CheckUserByEmail(ctx, strings.ToLower(req.Email)); err != nil {
+			logrus.Printf("check user error: %s", err)
+			return err
+		}
+
+		if err := u.service.CreateUserServ(ctx, newUser); err != nil {
+			logrus.Printf("save users error: %s", err)
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
-		logrus.Printf("save users error: %s", err)
 		return uuid.Nil, err
 	}
 
+	// The account exists by now; a lost email can be sent again.
+	if err = u.sendVerification(ctx, newUser.ID, newUser.Username, newUser.Email); err != nil {
+		logrus.Printf("send verification error: %s", err)
+	}
+
 	return newUser.ID, nil
 }
 
@@ -59,21 +104,39 @@ func (u *AuthUsecase) AuthenticateUser(ctx context.Context, req AuthInput) (*mod
 		return nil, err
 	}
 
-	jwt, err := u.tokenManager.NewJWT(user.UserID.String())
+	if u.account.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, models.ErrEmailNotVerified
+	}
+
+	// With two-factor authentication the password only gets a challenge,
+	// which VerifyTwoFactor exchanges for the tokens.
+	enabled, err := u.twoFactorEnabled(ctx, user.UserID)
 	if err != nil {
-		logrus.Printf("jwt error: %s", err)
 		return nil, err
 	}
 
-	resp := NewAuthResponse(jwt)
+	if enabled {
+		return u.newChallenge(ctx, user.UserID)
+	}
 
-	return resp, nil
+	// Every login starts a new refresh token family.
+	return u.issueTokens(ctx, user.UserID, uuid.New())
 }
 
-func NewAuthUsecase(service AuthService, hasher hash.Hasher, tokenManager *token_manager.TokenManager) *AuthUsecase {
+func NewAuthUsecase(
+	service AuthService,
+	hasher hash.Hasher,
+	tokenManager *token_manager.TokenManager,
+	transactor Transactor,
+	mailer mailer.Mailer,
+	account AccountSettings,
+) *AuthUsecase {
 	return &AuthUsecase{
 		service:      service,
 		hasher:       hasher,
 		tokenManager: tokenManager,
+		transactor:   transactor,
+		mailer:       mailer,
+		account:      account,
 	}
 }
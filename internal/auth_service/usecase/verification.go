@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/auth_service/models"
+	"notes-rew/internal/auth_service/service"
+	"notes-rew/internal/mailer"
+)
+
+// verificationClaims are signed into the verification token. The token
+// names the address it confirms, so it stops working once the user changes
+// their email.
+type verificationClaims struct {
+	UserID    uuid.UUID `json:"u"`
+	Email     string    `json:"e"`
+	ExpiresAt int64     `json:"x"`
+}
+
+// VerifyEmail confirms the address named by a token from a verification
+// email. Verifying twice is not an error.
+func (u *AuthUsecase) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := u.parseVerificationToken(token)
+	if err != nil {
+		return err
+	}
+
+	return u.service.MarkEmailVerified(ctx, claims.UserID, claims.Email, time.Now().UTC())
+}
+
+// ResendVerification mails a new verification link. Unknown and already
+// verified addresses are not an error, so that the endpoint doesn't reveal
+// who has an account.
+func (u *AuthUsecase) ResendVerification(ctx context.Context, email string) error {
+	user, err := u.service.AuthByEmail(ctx, service.SignInInput{Email: email})
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			logrus.Printf("verification requested for unknown email")
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return u.sendVerification(ctx, user.UserID, user.Username, user.Email)
+}
+
+func (u *AuthUsecase) sendVerification(ctx context.Context, userID uuid.UUID, username, email string) error {
+	token, err := u.newVerificationToken(userID, email)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nFollow this link to confirm your email address:\n\n%s\n\n"+
+				"The link expires in %s. If you didn't sign up, ignore this email.\n",
+			username, linkWithToken(u.account.VerifyEmailURL, token), u.account.VerifyEmailTTL,
+		),
+	})
+}
+
+func (u *AuthUsecase) newVerificationToken(userID uuid.UUID, email string) (string, error) {
+	payload, err := json.Marshal(verificationClaims{
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(u.account.VerifyEmailTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(u.signVerification(encoded)), nil
+}
+
+func (u *AuthUsecase) parseVerificationToken(token string) (verificationClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return verificationClaims{}, models.ErrInvalidVerifyToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, u.signVerification(encoded)) {
+		return verificationClaims{}, models.ErrInvalidVerifyToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return verificationClaims{}, models.ErrInvalidVerifyToken
+	}
+
+	var claims verificationClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return verificationClaims{}, models.ErrInvalidVerifyToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return verificationClaims{}, models.ErrInvalidVerifyToken
+	}
+
+	return claims, nil
+}
+
+func (u *AuthUsecase) signVerification(payload string) []byte {
+	mac := hmac.New(sha256.New, u.account.VerificationSecret)
+	mac.Write([]byte("email-verification:" + payload))
+
+	return mac.Sum(nil)
+}
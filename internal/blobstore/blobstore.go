@@ -0,0 +1,20 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the contents of attachments. Keys are slash-separated
+// paths chosen by the caller, such as "<note id>/<attachment id>".
+type BlobStore interface {
+	// Put stores the content of r under key and returns its size.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns a seekable reader, so that downloads can serve ranges.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -16,6 +16,12 @@ type Config struct {
 	GRPCServer    GRPCServer    `yaml:"grpc_server"`
 	GatewayServer GatewayServer `yaml:"grpc_gateway"`
 	Redis         Redis         `yaml:"redis"`
+	Cache         Cache         `yaml:"cache"`
+	Attachments   Attachments   `yaml:"attachments"`
+	Import        Import        `yaml:"import"`
+	Trash         Trash         `yaml:"trash"`
+	Auth          Auth          `yaml:"auth"`
+	Mail          Mail          `yaml:"mail"`
 	MigrationsDir string        `yaml:"migrations_dir" env:"MIGRATIONS_DIR"`
 	JwtSigning    string        `yaml:"jwt_signing" env-required:"true" env:"JWT_SIGNING"`
 	SaltHash      string        `yaml:"salt_hash" env-required:"true" env:"SALT_HASH"`
@@ -29,6 +35,16 @@ type DB struct {
 	UserName string `yaml:"username" env:"DB_USERNAME"`
 	Password string `yaml:"password" env:"DB_PASSWORD"`
 	Driver   string `yaml:"driver" env:"DB_DRIVER"`
+	Pool     DBPool `yaml:"pool"`
+}
+
+type DBPool struct {
+	MaxConns          int32         `yaml:"max_conns" env:"DB_POOL_MAX_CONNS" env-default:"10"`
+	MinConns          int32         `yaml:"min_conns" env:"DB_POOL_MIN_CONNS" env-default:"2"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"DB_POOL_MAX_CONN_LIFETIME" env-default:"1h"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"DB_POOL_MAX_CONN_IDLE_TIME" env-default:"30m"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"DB_POOL_HEALTH_CHECK_PERIOD" env-default:"1m"`
+	StatementTimeout  time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" env-default:"30s"`
 }
 
 type Redis struct {
@@ -37,6 +53,75 @@ type Redis struct {
 	DB       int    `yaml:"db" env:"REDIS_DB"`
 }
 
+type Cache struct {
+	NoteTTL time.Duration `yaml:"note_ttl" env:"CACHE_NOTE_TTL" env-default:"1h"`
+	ListTTL time.Duration `yaml:"list_ttl" env:"CACHE_LIST_TTL" env-default:"5m"`
+}
+
+type Attachments struct {
+	Dir string `yaml:"dir" env:"ATTACHMENTS_DIR" env-default:"./data/attachments"`
+	// MaxFileSize and UserQuota are in bytes.
+	MaxFileSize int64 `yaml:"max_file_size" env:"ATTACHMENTS_MAX_FILE_SIZE" env-default:"26214400"`
+	UserQuota   int64 `yaml:"user_quota" env:"ATTACHMENTS_USER_QUOTA" env-default:"524288000"`
+}
+
+type Import struct {
+	// MaxFileSize is in bytes.
+	MaxFileSize  int64         `yaml:"max_file_size" env:"IMPORT_MAX_FILE_SIZE" env-default:"104857600"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"IMPORT_POLL_INTERVAL" env-default:"2s"`
+	// StaleAfter is how long a running job may go without progress before
+	// another worker takes it over.
+	StaleAfter time.Duration `yaml:"stale_after" env:"IMPORT_STALE_AFTER" env-default:"10m"`
+}
+
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type Auth struct {
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"AUTH_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"AUTH_REFRESH_TOKEN_TTL" env-default:"720h"`
+	// JWTKeySet is the manifest of the RS256/EdDSA keys access tokens are
+	// signed with. Without it they are signed with HS256 and JwtSigning.
+	JWTKeySet   string `yaml:"jwt_key_set" env:"AUTH_JWT_KEY_SET"`
+	JWTIssuer   string `yaml:"jwt_issuer" env:"AUTH_JWT_ISSUER" env-default:"notes-rew"`
+	JWTAudience string `yaml:"jwt_audience" env:"AUTH_JWT_AUDIENCE" env-default:"notes-rew"`
+	// PasswordResetURL is the page of the client that sets the new
+	// password; the reset token is appended as the token query parameter.
+	PasswordResetURL string        `yaml:"password_reset_url" env:"AUTH_PASSWORD_RESET_URL" env-default:"http://localhost:8081/reset-password"`
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl" env:"AUTH_PASSWORD_RESET_TTL" env-default:"1h"`
+	// EmailVerification is what unverified accounts can't do: off, login,
+	// or notes to let them log in but not create notes.
+	EmailVerification string `yaml:"email_verification" env:"AUTH_EMAIL_VERIFICATION" env-default:"off"`
+	// VerifyEmailURL is the page of the client that confirms the address;
+	// the signed token is appended as the token query parameter.
+	VerifyEmailURL string        `yaml:"verify_email_url" env:"AUTH_VERIFY_EMAIL_URL" env-default:"http://localhost:8081/verify-email"`
+	VerifyEmailTTL time.Duration `yaml:"verify_email_ttl" env:"AUTH_VERIFY_EMAIL_TTL" env-default:"72h"`
+	// VerificationSecret signs verification links. It defaults to
+	// JwtSigning.
+	VerificationSecret string `yaml:"verification_secret" env:"AUTH_VERIFICATION_SECRET"`
+	// TwoFactorIssuer names the service in authenticator apps.
+	TwoFactorIssuer       string        `yaml:"two_factor_issuer" env:"AUTH_TWO_FACTOR_ISSUER" env-default:"notes-rew"`
+	TwoFactorChallengeTTL time.Duration `yaml:"two_factor_challenge_ttl" env:"AUTH_TWO_FACTOR_CHALLENGE_TTL" env-default:"5m"`
+}
+
+type Mail struct {
+	// Driver is smtp, or file to write messages into OutboxDir instead of
+	// sending them.
+	Driver    string `yaml:"driver" env:"MAIL_DRIVER" env-default:"file"`
+	From      string `yaml:"from" env:"MAIL_FROM" env-default:"notes@localhost"`
+	OutboxDir string `yaml:"outbox_dir" env:"MAIL_OUTBOX_DIR" env-default:"./data/outbox"`
+	SMTP      SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" env-default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
 type HTTPServer struct {
 	Address        string        `yaml:"address" env:"HTTP_SERVER_ADDRESS"`
 	ReadTimeout    time.Duration `yaml:"read_timeout" env:"HTTP_SERVER_READ_TIME_OUT"`
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('simple', coalesce(body, '')), 'B')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS notes_search_vector_idx ON notes USING GIN (search_vector);
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_revisions
+(
+    id         UUID PRIMARY KEY,
+    note_id    UUID      NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+    revision   INTEGER   NOT NULL,
+    title      TEXT      NOT NULL,
+    body       TEXT      NOT NULL,
+    tags       TEXT[],
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (note_id, revision)
+);
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
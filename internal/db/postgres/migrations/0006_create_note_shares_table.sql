@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_shares
+(
+    note_id    UUID      NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+    user_id    UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       TEXT      NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (note_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_shares_user_id_idx ON note_shares (user_id);
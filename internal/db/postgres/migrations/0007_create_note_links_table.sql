@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_links
+(
+    id            UUID PRIMARY KEY,
+    note_id       UUID      NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+    token_hash    TEXT      NOT NULL UNIQUE,
+    password_hash TEXT,
+    expires_at    TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_links_note_id_idx ON note_links (note_id);
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notebooks
+(
+    id         UUID PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    parent_id  UUID REFERENCES notebooks (id) ON DELETE CASCADE,
+    author     UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS notebooks_author_idx ON notebooks (author);
+CREATE INDEX IF NOT EXISTS notebooks_parent_id_idx ON notebooks (parent_id);
+
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS notebook_id UUID REFERENCES notebooks (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS notes_notebook_id_idx ON notes (notebook_id);
@@ -0,0 +1,11 @@
+-- +goose Up
+UPDATE notes
+SET tags = coalesce((SELECT array_agg(tag ORDER BY first_position)
+                     FROM (SELECT lower(regexp_replace(btrim(raw), '\s+', ' ', 'g')) AS tag,
+                                  min(position)                                     AS first_position
+                           FROM unnest(tags) WITH ORDINALITY AS t(raw, position)
+                           WHERE btrim(raw) <> ''
+                           GROUP BY 1) normalized), '{}')
+WHERE tags IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS notes_tags_idx ON notes USING GIN (tags);
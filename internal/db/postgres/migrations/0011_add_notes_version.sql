@@ -0,0 +1,3 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id           UUID PRIMARY KEY,
+    note_id      UUID      NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+    -- The author of the note, whose storage quota the file counts against.
+    owner_id     UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    filename     TEXT      NOT NULL,
+    content_type TEXT      NOT NULL,
+    size         BIGINT    NOT NULL,
+    storage_key  TEXT      NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachments_note_id_idx ON attachments (note_id);
+CREATE INDEX IF NOT EXISTS attachments_owner_id_idx ON attachments (owner_id);
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS import_jobs
+(
+    id            UUID PRIMARY KEY,
+    owner_id      UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    format        TEXT      NOT NULL,
+    -- pending, running, done or failed.
+    status        TEXT      NOT NULL,
+    -- Blob holding the uploaded file until the job is over.
+    source_key    TEXT      NOT NULL,
+    created_count INTEGER   NOT NULL DEFAULT 0,
+    skipped_count INTEGER   NOT NULL DEFAULT 0,
+    failed_count  INTEGER   NOT NULL DEFAULT 0,
+    failures      JSONB     NOT NULL DEFAULT '[]',
+    error         TEXT,
+    created_at    TIMESTAMP NOT NULL,
+    updated_at    TIMESTAMP NOT NULL,
+    finished_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_owner_id_idx ON import_jobs (owner_id);
+CREATE INDEX IF NOT EXISTS import_jobs_status_idx ON import_jobs (status, created_at);
+
+-- imported_notes remembers which items have been imported already, so that
+-- importing the same file again creates no duplicates. Purging a note forgets
+-- its item.
+CREATE TABLE IF NOT EXISTS imported_notes
+(
+    owner_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    item_key TEXT NOT NULL,
+    note_id  UUID NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+    job_id   UUID NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+    PRIMARY KEY (owner_id, item_key)
+);
+
+CREATE INDEX IF NOT EXISTS imported_notes_note_id_idx ON imported_notes (note_id);
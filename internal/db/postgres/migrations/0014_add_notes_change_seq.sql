@@ -0,0 +1,86 @@
+-- +goose Up
+-- Every write to a note gives it the next number of its author's change
+-- sequence, which sync tokens point into. The numbers are assigned by
+-- triggers so that no writer can miss them, including the notebooks service
+-- and the ON DELETE SET NULL of notebook_id. The author's counter row stays
+-- locked until the writing transaction ends, so the numbers become visible
+-- in order and a sync never skips a change that commits after it.
+CREATE TABLE IF NOT EXISTS sync_sequences
+(
+    user_id UUID PRIMARY KEY,
+    seq     BIGINT NOT NULL
+);
+
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+
+UPDATE notes
+SET change_seq = numbered.seq
+FROM (SELECT id, row_number() OVER (PARTITION BY author ORDER BY updated_at, id) AS seq FROM notes) AS numbered
+WHERE notes.id = numbered.id;
+
+INSERT INTO sync_sequences (user_id, seq)
+SELECT author, max(change_seq)
+FROM notes
+GROUP BY author
+ON CONFLICT (user_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS notes_author_change_seq_idx ON notes (author, change_seq);
+
+-- Purged notes leave a tombstone behind, so that clients that synced them
+-- learn they are gone.
+CREATE TABLE IF NOT EXISTS note_tombstones
+(
+    note_id    UUID PRIMARY KEY,
+    author     UUID      NOT NULL,
+    change_seq BIGINT    NOT NULL,
+    deleted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_tombstones_author_change_seq_idx ON note_tombstones (author, change_seq);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION next_change_seq(author UUID) RETURNS BIGINT AS
+$$
+INSERT INTO sync_sequences (user_id, seq)
+VALUES ($1, 1)
+ON CONFLICT (user_id) DO UPDATE SET seq = sync_sequences.seq + 1
+RETURNING seq;
+$$ LANGUAGE sql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notes_set_change_seq() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.change_seq := next_change_seq(NEW.author);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notes_leave_tombstone() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO note_tombstones (note_id, author, change_seq, deleted_at)
+    VALUES (OLD.id, OLD.author, next_change_seq(OLD.author), now() AT TIME ZONE 'UTC')
+    ON CONFLICT (note_id) DO UPDATE
+        SET change_seq = EXCLUDED.change_seq,
+            deleted_at = EXCLUDED.deleted_at;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS notes_change_seq ON notes;
+CREATE TRIGGER notes_change_seq
+    BEFORE INSERT OR UPDATE ON notes
+    FOR EACH ROW
+EXECUTE FUNCTION notes_set_change_seq();
+
+DROP TRIGGER IF EXISTS notes_tombstone ON notes;
+CREATE TRIGGER notes_tombstone
+    AFTER DELETE ON notes
+    FOR EACH ROW
+EXECUTE FUNCTION notes_leave_tombstone();
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- Accounts made before verification existed keep working.
+UPDATE users
+SET email_verified_at = created_at
+WHERE email_verified_at IS NULL;
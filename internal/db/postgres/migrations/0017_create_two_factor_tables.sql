@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_totp
+(
+    user_id        UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         TEXT      NOT NULL,
+    -- Two-factor authentication is enabled once the user confirmed a code.
+    confirmed_at   TIMESTAMP,
+    -- The step of the last accepted code, so that no code works twice.
+    last_used_step BIGINT    NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS totp_recovery_codes_user_id_idx ON totp_recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS two_factor_challenges
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT      NOT NULL UNIQUE,
+    attempts   INT       NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS two_factor_challenges_user_id_idx ON two_factor_challenges (user_id);
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS personal_access_tokens
+(
+    id           UUID PRIMARY KEY,
+    user_id      UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT      NOT NULL,
+    token_hash   TEXT      NOT NULL UNIQUE,
+    scopes       TEXT[]    NOT NULL,
+    -- NULL for tokens that don't expire.
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL,
+    UNIQUE (user_id, name)
+);
@@ -3,13 +3,14 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 	"log"
 	"notes-rew/internal/config"
+	"strconv"
 )
 
-func ConnectionPostgresDB(ctx context.Context, c config.Config) (*pgx.Conn, error) {
+func ConnectionPostgresDB(ctx context.Context, c config.Config) (*pgxpool.Pool, error) {
 	connStr := fmt.Sprintf(
 		"user=%s password=%s host=%s port=%s dbname=%s sslmode=%s",
 		c.DB.UserName,
@@ -20,19 +21,36 @@ func ConnectionPostgresDB(ctx context.Context, c config.Config) (*pgx.Conn, erro
 		c.DB.SSLMode,
 	)
 
-	conn, err := pgx.Connect(ctx, connStr)
+	poolConfig, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the database config: %v", err)
+	}
+
+	poolConfig.MaxConns = c.DB.Pool.MaxConns
+	poolConfig.MinConns = c.DB.Pool.MinConns
+	poolConfig.MaxConnLifetime = c.DB.Pool.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = c.DB.Pool.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = c.DB.Pool.HealthCheckPeriod
+
+	// Every connection of the pool gets the timeout, so a runaway query
+	// can't hold a connection forever.
+	if c.DB.Pool.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] =
+			strconv.FormatInt(c.DB.Pool.StatementTimeout.Milliseconds(), 10)
+	}
 
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		logrus.Fatalf("failed to connect to the database: %v", err)
 		return nil, err
 	}
 
-	if err = conn.Ping(ctx); err != nil {
-		conn.Close(ctx)
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping the database: %v", err)
 	}
 
 	log.Println("database connection successfully")
 
-	return conn, nil
+	return pool, nil
 }
@@ -13,9 +13,11 @@ func ConnectionRedisStorage(ctx context.Context, cfg config.Config) (*redis.Clie
 		DB:       cfg.Redis.DB,
 	})
 
+	// The client is returned even when the ping fails: it connects lazily,
+	// so it starts working as soon as Redis is back.
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
-		return nil, err
+		return client, err
 	}
 
 	return client, nil
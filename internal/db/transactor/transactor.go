@@ -0,0 +1,84 @@
+package transactor
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+type txState struct {
+	tx          pgx.Tx
+	afterCommit []func()
+}
+
+// Querier is the part of pgx shared by the pool and a transaction, so that
+// storages run the same queries inside and outside of one.
+type Querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Transactor runs a unit of work in a single database transaction. The
+// transaction travels in the context: storages pick it up through Conn.
+type Transactor struct {
+	pool *pgxpool.Pool
+}
+
+// WithinTransaction commits when fn returns nil and rolls back otherwise.
+// Calls nested in fn join the outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+
+	err := pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range state.afterCommit {
+		f()
+	}
+
+	return nil
+}
+
+// AfterCommit runs f once the transaction carried by ctx commits, and drops
+// it on rollback. Without a transaction f runs right away. It is meant for
+// side effects outside the database, such as cache invalidation, that must
+// not be seen before the data they depend on.
+func AfterCommit(ctx context.Context, f func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, f)
+		return
+	}
+
+	f()
+}
+
+// Conn returns the transaction started by WithinTransaction, or the pool
+// when there is none.
+func Conn(ctx context.Context, pool *pgxpool.Pool) Querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+
+	return pool
+}
+
+func NewTransactor(pool *pgxpool.Pool) *Transactor {
+	return &Transactor{
+		pool: pool,
+	}
+}
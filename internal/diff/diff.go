@@ -0,0 +1,222 @@
+package diff
+
+import "strings"
+
+// Past these sizes of the part that differs, Lines stops looking for the
+// shortest edit script and replaces all of it, which keeps diffing huge or
+// unrelated texts cheap.
+const (
+	maxLines = 5000
+	maxBytes = 1 << 20
+)
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns a line-by-line edit script turning a into b. It uses the
+// linear space variant of Myers' O(ND) algorithm, so time grows with the
+// size of the change and memory with the size of the texts.
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+	prefix, suffix := commonAffixes(x, y)
+
+	d := &differ{lines: make([]Line, 0, len(x)+len(y)-prefix-suffix)}
+	d.emit(OpEqual, x[:prefix])
+
+	changedX, changedY := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+	if len(changedX)+len(changedY) > maxLines || size(changedX)+size(changedY) > maxBytes {
+		d.replace(changedX, changedY)
+	} else {
+		d.compare(changedX, changedY)
+	}
+
+	d.emit(OpEqual, x[len(x)-suffix:])
+
+	return d.lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// commonAffixes returns how many lines x and y share at the start and, after
+// that, at the end.
+func commonAffixes(x, y []string) (prefix, suffix int) {
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
+
+func size(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		n += len(line)
+	}
+
+	return n
+}
+
+type differ struct {
+	lines []Line
+}
+
+func (d *differ) emit(op Op, texts []string) {
+	for _, text := range texts {
+		d.lines = append(d.lines, Line{Op: op, Text: text})
+	}
+}
+
+func (d *differ) replace(x, y []string) {
+	d.emit(OpDelete, x)
+	d.emit(OpInsert, y)
+}
+
+// compare appends a shortest edit script turning x into y. It splits the
+// problem where the forward and reverse searches for such a script meet and
+// recurses on both halves, so only two rows of the search are kept at a
+// time.
+func (d *differ) compare(x, y []string) {
+	prefix, suffix := commonAffixes(x, y)
+	d.emit(OpEqual, x[:prefix])
+
+	changedX, changedY := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+
+	switch {
+	case len(changedX) == 0:
+		d.emit(OpInsert, changedY)
+	case len(changedY) == 0:
+		d.emit(OpDelete, changedX)
+	default:
+		i, j, ok := middle(changedX, changedY)
+		if ok {
+			d.compare(changedX[:i], changedY[:j])
+			d.compare(changedX[i:], changedY[j:])
+		} else {
+			d.replace(changedX, changedY)
+		}
+	}
+
+	d.emit(OpEqual, x[len(x)-suffix:])
+}
+
+// middle returns a point on a shortest edit script turning x into y that
+// splits it into two smaller problems. x and y must be non-empty and differ
+// in their first and last lines.
+func middle(x, y []string) (int, int, bool) {
+	n, m := len(x), len(y)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	width := 2*maxD + 2
+
+	// forward[offset+k] is the furthest x reached on diagonal k searching
+	// from the start, reverse the same searching back from the end.
+	forward := make([]int, width)
+	reverse := make([]int, width)
+	for k := range forward {
+		forward[k] = -1
+		reverse[k] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	delta := n - m
+	// With an odd delta the searches meet going forward, otherwise going
+	// in reverse.
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edit graph are skipped from then on.
+	var fStart, fEnd, rStart, rEnd int
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var i int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				i = forward[offset+k+1]
+			} else {
+				i = forward[offset+k-1] + 1
+			}
+
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			forward[offset+k] = i
+
+			switch {
+			case i > n:
+				fEnd += 2
+			case j > m:
+				fStart += 2
+			case odd:
+				rk := offset + delta - k
+				if rk >= 0 && rk < width && reverse[rk] != -1 && i >= n-reverse[rk] {
+					return split(n, m, i, j)
+				}
+			}
+		}
+
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			var i int
+			if k == -d || (k != d && reverse[offset+k-1] < reverse[offset+k+1]) {
+				i = reverse[offset+k+1]
+			} else {
+				i = reverse[offset+k-1] + 1
+			}
+
+			j := i - k
+			for i < n && j < m && x[n-1-i] == y[m-1-j] {
+				i++
+				j++
+			}
+			reverse[offset+k] = i
+
+			switch {
+			case i > n:
+				rEnd += 2
+			case j > m:
+				rStart += 2
+			case !odd:
+				fk := offset + delta - k
+				if fk >= 0 && fk < width && forward[fk] != -1 {
+					fi := forward[fk]
+					if fi >= n-i {
+						return split(n, m, fi, fi-(fk-offset))
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// split accepts (i, j) as the point to split at if both halves are smaller
+// than the whole.
+func split(n, m, i, j int) (int, int, bool) {
+	if (i == 0 && j == 0) || (i == n && j == m) {
+		return 0, 0, false
+	}
+
+	return i, j, true
+}
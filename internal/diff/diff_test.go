@@ -0,0 +1,187 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{
+			name: "empty",
+			want: []Line{},
+		},
+		{
+			name: "from empty",
+			b:    "a\nb\n",
+			want: []Line{{OpInsert, "a"}, {OpInsert, "b"}},
+		},
+		{
+			name: "to empty",
+			a:    "a\nb",
+			want: []Line{{OpDelete, "a"}, {OpDelete, "b"}},
+		},
+		{
+			name: "identical",
+			a:    "a\nb\nc",
+			b:    "a\nb\nc",
+			want: []Line{{OpEqual, "a"}, {OpEqual, "b"}, {OpEqual, "c"}},
+		},
+		{
+			name: "disjoint",
+			a:    "a\nb",
+			b:    "c\nd",
+			want: []Line{{OpDelete, "a"}, {OpDelete, "b"}, {OpInsert, "c"}, {OpInsert, "d"}},
+		},
+		{
+			name: "changed line",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			want: []Line{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "x"}, {OpEqual, "c"}},
+		},
+		{
+			name: "inserted and deleted lines",
+			a:    "a\nb\nc\nd\ne",
+			b:    "x\na\nc\nd\ny\ne",
+			want: []Line{
+				{OpInsert, "x"}, {OpEqual, "a"}, {OpDelete, "b"}, {OpEqual, "c"},
+				{OpEqual, "d"}, {OpInsert, "y"}, {OpEqual, "e"},
+			},
+		},
+		{
+			name: "trailing newline",
+			a:    "a\n",
+			b:    "a",
+			want: []Line{{OpEqual, "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lines(tt.a, tt.b)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesShortest(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 500; n++ {
+		a, b := randomText(rnd), randomText(rnd)
+
+		got := Lines(a, b)
+		checkScript(t, a, b, got)
+
+		equal := 0
+		for _, line := range got {
+			if line.Op == OpEqual {
+				equal++
+			}
+		}
+
+		if want := lcs(splitLines(a), splitLines(b)); equal != want {
+			t.Fatalf("Lines(%q, %q) keeps %d lines, want %d", a, b, equal, want)
+		}
+	}
+}
+
+func TestLinesLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{
+			name: "disjoint",
+			a:    numbered("a", 8000),
+			b:    numbered("b", 8000),
+		},
+		{
+			name: "scattered edits",
+			a:    numbered("line", 2000),
+			b:    strings.ReplaceAll(numbered("line", 2000), "0\n", "0 edited\n"),
+		},
+		{
+			name: "long lines",
+			a:    strings.Repeat(strings.Repeat("a", 1000)+"\n", 2000),
+			b:    strings.Repeat(strings.Repeat("b", 1000)+"\n", 2000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkScript(t, tt.a, tt.b, Lines(tt.a, tt.b))
+		})
+	}
+}
+
+// checkScript fails unless script turns a into b.
+func checkScript(t *testing.T, a, b string, script []Line) {
+	t.Helper()
+
+	var from, to []string
+
+	for _, line := range script {
+		if line.Op != OpInsert {
+			from = append(from, line.Text)
+		}
+		if line.Op != OpDelete {
+			to = append(to, line.Text)
+		}
+	}
+
+	if strings.Join(from, "\n") != strings.Join(splitLines(a), "\n") {
+		t.Fatalf("script doesn't start from a")
+	}
+	if strings.Join(to, "\n") != strings.Join(splitLines(b), "\n") {
+		t.Fatalf("script doesn't end at b")
+	}
+}
+
+func randomText(rnd *rand.Rand) string {
+	lines := make([]string, rnd.Intn(12))
+	for i := range lines {
+		lines[i] = string(rune('a' + rnd.Intn(4)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func numbered(prefix string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%s %d\n", prefix, i)
+	}
+
+	return b.String()
+}
+
+// lcs returns the length of the longest common subsequence of x and y.
+func lcs(x, y []string) int {
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+
+	for i := range x {
+		for j := range y {
+			switch {
+			case x[i] == y[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(y)]
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileOutbox writes every message as an .eml file into a directory instead
+// of sending it, for local development and tests. Files are named after the
+// time they were written, so they list in order.
+type FileOutbox struct {
+	dir  string
+	from string
+}
+
+func (o *FileOutbox) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+
+	data, err := compose(o.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	path := filepath.Join(o.dir, name)
+
+	// The message appears under its final name only once it is complete.
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func NewFileOutbox(dir, from string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileOutbox{dir: dir, from: from}, nil
+}
@@ -0,0 +1,120 @@
+// Package markdown renders note bodies written in CommonMark with the GitHub
+// extensions to HTML that is safe to embed into a page.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading is an entry of the table of contents. ID is the anchor of the
+// heading in the rendered HTML.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+type Document struct {
+	HTML string
+	TOC  []Heading
+}
+
+// Raw HTML in the source is dropped by goldmark already, the sanitizer is
+// the second line of defence against whatever the renderer lets through.
+var (
+	converter = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+	policy = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// Heading anchors, for the table of contents to link to.
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).
+		OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+
+	// Task list items are rendered as disabled checkboxes.
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	return p
+}
+
+// Render converts the source to sanitized HTML and collects its headings.
+func Render(source string) (Document, error) {
+	src := []byte(source)
+
+	doc := converter.Parser().Parse(text.NewReader(src))
+
+	var buf bytes.Buffer
+	if err := converter.Renderer().Render(&buf, src, doc); err != nil {
+		return Document{}, err
+	}
+
+	return Document{
+		HTML: policy.Sanitize(buf.String()),
+		TOC:  headings(doc, src),
+	}, nil
+}
+
+func headings(doc ast.Node, src []byte) []Heading {
+	toc := make([]Heading, 0)
+
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		heading, ok := node.(*ast.Heading)
+		if !ok {
+			continue
+		}
+
+		entry := Heading{
+			Level: heading.Level,
+			Text:  plainText(heading, src),
+		}
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				entry.ID = string(b)
+			}
+		}
+
+		toc = append(toc, entry)
+	}
+
+	return toc
+}
+
+// plainText joins the text of the node's inline children, dropping emphasis,
+// links and other markup.
+func plainText(node ast.Node, src []byte) string {
+	var buf bytes.Buffer
+
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return buf.String()
+}
@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"context"
-	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"net/http"
 	"notes-rew/internal/token_manager"
@@ -46,19 +45,19 @@ func HttpInterceptor(tm *token_manager.TokenManager, next *runtime.ServeMux) htt
 			return
 		}
 
-		userID, err := tm.ParseToken(headerParts[1])
+		identity, err := tm.Authenticate(req.Context(), headerParts[1])
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		parseUUID, err := uuid.Parse(userID)
-		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
+		if !allowed(identity, gatewayScope(req)) {
+			w.WriteHeader(http.StatusForbidden)
 			return
 		}
 
-		ctx := context.WithValue(req.Context(), UserCtx, parseUUID)
+		ctx := context.WithValue(req.Context(), UserCtx, identity.UserID)
+		ctx = context.WithValue(ctx, TokenCtx, headerParts[1])
 
 		next.ServeHTTP(w, req.WithContext(ctx))
 	}
@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"context"
-	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -13,8 +12,18 @@ import (
 
 const grpcService = "AuthService"
 
+// authenticatedAuthMethods are the AuthService methods that, unlike login or
+// sign-up, act on behalf of an already authenticated user.
+var authenticatedAuthMethods = map[string]bool{
+	"/auth_service.service.v2.AuthService/Logout":           true,
+	"/auth_service.service.v2.AuthService/LogoutAll":        true,
+	"/auth_service.service.v2.AuthService/EnrollTwoFactor":  true,
+	"/auth_service.service.v2.AuthService/ConfirmTwoFactor": true,
+	"/auth_service.service.v2.AuthService/DisableTwoFactor": true,
+}
+
 func isAuthMethod(info string) bool {
-	return strings.Contains(info, grpcService)
+	return strings.Contains(info, grpcService) && !authenticatedAuthMethods[info]
 }
 
 func UnaryTokenInterceptor(tm *token_manager.TokenManager) grpc.UnaryServerInterceptor {
@@ -29,33 +38,78 @@ func UnaryTokenInterceptor(tm *token_manager.TokenManager) grpc.UnaryServerInter
 			return handler(ctx, req)
 		}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+		ctx, err := authenticate(ctx, tm, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
 
-		authHeader := md.Get(AuthorizationHeader)
-		if len(authHeader) != 1 {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header")
-		}
+		return handler(ctx, req)
+	}
+}
 
-		headerParts := strings.Split(authHeader[0], " ")
-		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header")
-		}
+// StreamTokenInterceptor authenticates streaming calls the way
+// UnaryTokenInterceptor does unary ones.
+func StreamTokenInterceptor(tm *token_manager.TokenManager) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
 
-		userID, err := tm.ParseToken(headerParts[1])
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		if isAuthMethod(info.FullMethod) {
+			return handler(srv, ss)
 		}
 
-		parseUUID, err := uuid.Parse(userID)
+		ctx, err := authenticate(ss.Context(), tm, info.FullMethod)
 		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid user id")
+			return err
 		}
 
-		ctx = context.WithValue(ctx, UserCtx, parseUUID)
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
 
-		return handler(ctx, req)
+// authenticatedStream carries the context holding the caller's identity.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate checks the bearer token in the call metadata, and the scope
+// of a personal access token against rpcScopes, and returns ctx with the
+// user and the token in it.
+func authenticate(ctx context.Context, tm *token_manager.TokenManager, method string) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	authHeader := md.Get(AuthorizationHeader)
+	if len(authHeader) != 1 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header")
+	}
+
+	headerParts := strings.Split(authHeader[0], " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header")
+	}
+
+	identity, err := tm.Authenticate(ctx, headerParts[1])
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
 	}
+
+	if !allowed(identity, rpcScopes[method]) {
+		return nil, status.Errorf(codes.PermissionDenied, "insufficient scope")
+	}
+
+	ctx = context.WithValue(ctx, UserCtx, identity.UserID)
+	ctx = context.WithValue(ctx, TokenCtx, headerParts[1])
+
+	return ctx, nil
 }
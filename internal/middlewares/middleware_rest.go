@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"context"
-	"github.com/google/uuid"
 	"net/http"
 	"notes-rew/internal/token_manager"
 	"strings"
@@ -11,9 +10,12 @@ import (
 const (
 	AuthorizationHeader = "Authorization"
 	UserCtx             = "userID"
+	TokenCtx            = "accessToken"
 )
 
-func UserIdentity(tm *token_manager.TokenManager) func(next http.Handler) http.Handler {
+// UserIdentity authenticates the request with an access token or a personal
+// access token, which also needs the scope policy asks for.
+func UserIdentity(tm *token_manager.TokenManager, policy ScopePolicy) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -37,7 +39,7 @@ func UserIdentity(tm *token_manager.TokenManager) func(next http.Handler) http.H
 				return
 			}
 
-			userID, err := tm.ParseToken(headerParts[1])
+			identity, err := tm.Authenticate(r.Context(), headerParts[1])
 			if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				_, err = w.Write([]byte("invalid token"))
@@ -47,19 +49,28 @@ func UserIdentity(tm *token_manager.TokenManager) func(next http.Handler) http.H
 				return
 			}
 
-			parseUUID, err := uuid.Parse(userID)
-			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, err = w.Write([]byte("invalid user id"))
+			if !allowed(identity, policy(r)) {
+				w.WriteHeader(http.StatusForbidden)
+				_, err = w.Write([]byte("insufficient scope"))
 				if err != nil {
 					return
 				}
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserCtx, parseUUID)
+			ctx := context.WithValue(r.Context(), UserCtx, identity.UserID)
+			ctx = context.WithValue(ctx, TokenCtx, headerParts[1])
 			next.ServeHTTP(w, r.WithContext(ctx))
 
 		})
 	}
 }
+
+// allowed reports whether identity may make a request that needs scope.
+func allowed(identity *token_manager.Identity, scope string) bool {
+	if !identity.Personal {
+		return true
+	}
+
+	return scope != "" && identity.HasScope(scope)
+}
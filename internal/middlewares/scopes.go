@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The scopes a personal access token can be granted.
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+	ScopeUsersRead  = "users:read"
+)
+
+// Scopes lists every scope, in the order they are documented.
+var Scopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeUsersRead}
+
+// ScopePolicy names the scope a personal access token needs for a request,
+// or "" when personal access tokens can't make it at all.
+type ScopePolicy func(r *http.Request) string
+
+// ReadWrite requires read for requests that only read and write for the
+// others. Either may be "" to refuse personal access tokens.
+func ReadWrite(read, write string) ScopePolicy {
+	return func(r *http.Request) string {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return read
+		default:
+			return write
+		}
+	}
+}
+
+// SessionOnly refuses personal access tokens, for routes that manage the
+// account, such as logging out or creating more tokens.
+func SessionOnly(*http.Request) string {
+	return ""
+}
+
+// gatewayScopes are the scope policies of the gRPC-Gateway routes, by path
+// prefix. Routes missing here refuse personal access tokens.
+var gatewayScopes = map[string]ScopePolicy{
+	"/v1/notes": ReadWrite(ScopeNotesRead, ScopeNotesWrite),
+	"/v1/users": ReadWrite(ScopeUsersRead, ""),
+}
+
+func gatewayScope(r *http.Request) string {
+	for prefix, policy := range gatewayScopes {
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return policy(r)
+		}
+	}
+
+	return ""
+}
+
+// rpcScopes are the scopes personal access tokens need for each RPC. RPCs
+// missing here refuse them.
+var rpcScopes = map[string]string{
+	"/notes_service.service.v1.NotesService/GetNote":    ScopeNotesRead,
+	"/notes_service.service.v1.NotesService/GetNotes":   ScopeNotesRead,
+	"/notes_service.service.v1.NotesService/CreateNote": ScopeNotesWrite,
+	"/notes_service.service.v1.NotesService/UpdateNote": ScopeNotesWrite,
+	"/notes_service.service.v1.NotesService/DeleteNote": ScopeNotesWrite,
+
+	"/notes_service.service.v2.NotesService/ListNotes":   ScopeNotesRead,
+	"/notes_service.service.v2.NotesService/SearchNotes": ScopeNotesRead,
+	"/notes_service.service.v2.NotesService/GetNote":     ScopeNotesRead,
+	"/notes_service.service.v2.NotesService/RenderNote":  ScopeNotesRead,
+	"/notes_service.service.v2.NotesService/WatchNotes":  ScopeNotesRead,
+	"/notes_service.service.v2.NotesService/UpdateNote":  ScopeNotesWrite,
+	"/notes_service.service.v2.NotesService/PatchNote":   ScopeNotesWrite,
+	"/notes_service.service.v2.NotesService/MoveNote":    ScopeNotesWrite,
+
+	"/notebooks_service.service.v1.NotebooksService/GetNotebook":         ScopeNotesRead,
+	"/notebooks_service.service.v1.NotebooksService/ListNotebooks":       ScopeNotesRead,
+	"/notebooks_service.service.v1.NotebooksService/GetNotebookContents": ScopeNotesRead,
+	"/notebooks_service.service.v1.NotebooksService/CreateNotebook":      ScopeNotesWrite,
+	"/notebooks_service.service.v1.NotebooksService/RenameNotebook":      ScopeNotesWrite,
+	"/notebooks_service.service.v1.NotebooksService/MoveNotebook":        ScopeNotesWrite,
+	"/notebooks_service.service.v1.NotebooksService/DeleteNotebook":      ScopeNotesWrite,
+
+	"/users_service.service.v1.UsersService/GetUser": ScopeUsersRead,
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb_notebooks_model "notes-rew/api/gen/go/notebooks_service/model/v1"
+	"notes-rew/internal/notebooks_service/models"
+)
+
+func NewNotebook(notebook models.Notebook) *pb_notebooks_model.Notebook {
+	var parentID string
+	if notebook.ParentID != nil {
+		parentID = notebook.ParentID.String()
+	}
+
+	return &pb_notebooks_model.Notebook{
+		Id:        notebook.ID.String(),
+		Name:      notebook.Name,
+		ParentId:  parentID,
+		Author:    notebook.Author.String(),
+		CreatedAt: timestamppb.New(notebook.CreatedAt),
+		UpdatedAt: timestamppb.New(notebook.UpdatedAt),
+	}
+}
+
+func NewNotebooks(notebooks []models.Notebook) []*pb_notebooks_model.Notebook {
+	resp := make([]*pb_notebooks_model.Notebook, 0, len(notebooks))
+	for _, notebook := range notebooks {
+		resp = append(resp, NewNotebook(notebook))
+	}
+
+	return resp
+}
+
+func NewNotebookContentsResponse(contents *models.NotebookContents) *pb_notebooks_model.NotebookContentsResponse {
+	notes := make([]*pb_notebooks_model.NotebookNote, 0, len(contents.Notes))
+	for _, note := range contents.Notes {
+		notes = append(notes, &pb_notebooks_model.NotebookNote{
+			Id:        note.ID.String(),
+			Title:     note.Title,
+			Tags:      note.Tags,
+			UpdatedAt: timestamppb.New(note.UpdatedAt),
+		})
+	}
+
+	return &pb_notebooks_model.NotebookContentsResponse{
+		Notebook:  NewNotebook(contents.Notebook),
+		Notebooks: NewNotebooks(contents.Notebooks),
+		Notes:     notes,
+	}
+}
+
+// ParseOptionalID parses an ID field where the empty string stands for "none".
+func ParseOptionalID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,247 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	pb_notebooks_model "notes-rew/api/gen/go/notebooks_service/model/v1"
+	pb_notebooks_service "notes-rew/api/gen/go/notebooks_service/service/v1"
+	"notes-rew/internal/notebooks_service/models"
+	"notes-rew/internal/notebooks_service/usecase"
+)
+
+const (
+	userIDKey     = "userID"
+	maxNameLength = 100
+)
+
+type NotebookUsecase interface {
+	CreateNotebook(ctx context.Context, req usecase.CreateNotebookInput) (uuid.UUID, error)
+	ReadNotebook(ctx context.Context, id, currentUserID uuid.UUID) (*models.Notebook, error)
+	ReadNotebooks(ctx context.Context, currentUserID uuid.UUID) ([]models.Notebook, error)
+	ReadNotebookContents(ctx context.Context, id, currentUserID uuid.UUID) (*models.NotebookContents, error)
+	RenameNotebook(ctx context.Context, id, currentUserID uuid.UUID, name string) error
+	MoveNotebook(ctx context.Context, id, currentUserID uuid.UUID, parentID *uuid.UUID) error
+	DeleteNotebook(ctx context.Context, id, currentUserID uuid.UUID, mode string) error
+}
+
+type NotebooksServer struct {
+	usecase NotebookUsecase
+	pb_notebooks_service.UnimplementedNotebooksServiceServer
+}
+
+func (n *NotebooksServer) CreateNotebook(
+	ctx context.Context,
+	req *pb_notebooks_model.CreateNotebookRequest,
+) (*pb_notebooks_model.NotebookIDResponse, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	if err := validateName(req.Name); err != nil {
+		return nil, err
+	}
+
+	parentID, err := ParseOptionalID(req.ParentId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid parent id")
+	}
+
+	notebookID, err := n.usecase.CreateNotebook(ctx, usecase.CreateNotebookInput{
+		Name:     req.Name,
+		ParentID: parentID,
+		Author:   currentUserID,
+	})
+	if err != nil {
+		return nil, notebookError(err)
+	}
+
+	return &pb_notebooks_model.NotebookIDResponse{Id: notebookID.String()}, nil
+}
+
+func (n *NotebooksServer) GetNotebook(
+	ctx context.Context,
+	req *pb_notebooks_model.NotebookIDRequest,
+) (*pb_notebooks_model.Notebook, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebookID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	notebook, err := n.usecase.ReadNotebook(ctx, notebookID, currentUserID)
+	if err != nil {
+		return nil, notebookError(err)
+	}
+
+	return NewNotebook(*notebook), nil
+}
+
+func (n *NotebooksServer) ListNotebooks(
+	ctx context.Context,
+	_ *emptypb.Empty,
+) (*pb_notebooks_model.ListNotebooksResponse, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebooks, err := n.usecase.ReadNotebooks(ctx, currentUserID)
+	if err != nil {
+		return nil, notebookError(err)
+	}
+
+	return &pb_notebooks_model.ListNotebooksResponse{Notebooks: NewNotebooks(notebooks)}, nil
+}
+
+func (n *NotebooksServer) GetNotebookContents(
+	ctx context.Context,
+	req *pb_notebooks_model.NotebookIDRequest,
+) (*pb_notebooks_model.NotebookContentsResponse, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebookID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	contents, err := n.usecase.ReadNotebookContents(ctx, notebookID, currentUserID)
+	if err != nil {
+		return nil, notebookError(err)
+	}
+
+	return NewNotebookContentsResponse(contents), nil
+}
+
+func (n *NotebooksServer) RenameNotebook(
+	ctx context.Context,
+	req *pb_notebooks_model.RenameNotebookRequest,
+) (*emptypb.Empty, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebookID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	if err = validateName(req.Name); err != nil {
+		return nil, err
+	}
+
+	if err = n.usecase.RenameNotebook(ctx, notebookID, currentUserID, req.Name); err != nil {
+		return nil, notebookError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (n *NotebooksServer) MoveNotebook(
+	ctx context.Context,
+	req *pb_notebooks_model.MoveNotebookRequest,
+) (*emptypb.Empty, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebookID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	parentID, err := ParseOptionalID(req.ParentId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid parent id")
+	}
+
+	if err = n.usecase.MoveNotebook(ctx, notebookID, currentUserID, parentID); err != nil {
+		return nil, notebookError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (n *NotebooksServer) DeleteNotebook(
+	ctx context.Context,
+	req *pb_notebooks_model.DeleteNotebookRequest,
+) (*emptypb.Empty, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	notebookID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	if err = n.usecase.DeleteNotebook(ctx, notebookID, currentUserID, req.Mode); err != nil {
+		return nil, notebookError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return status.Error(codes.InvalidArgument, "name must be 1 to 100 characters long")
+	}
+
+	return nil
+}
+
+func notebookError(err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidDeleteMode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrNotebookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrNotebookCycle):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	logrus.Error("error managing notebooks: ", err)
+	return status.Error(codes.Internal, "failed to process notebook")
+}
+
+func NewNotebooksServer(
+	usecase NotebookUsecase,
+	unimplementedNotebooksServiceServer pb_notebooks_service.UnimplementedNotebooksServiceServer,
+) *NotebooksServer {
+	return &NotebooksServer{
+		usecase:                             usecase,
+		UnimplementedNotebooksServiceServer: unimplementedNotebooksServiceServer,
+	}
+}
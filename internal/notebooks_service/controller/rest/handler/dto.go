@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"github.com/google/uuid"
+	"notes-rew/internal/notebooks_service/usecase"
+)
+
+type CreateNotebookRequest struct {
+	Name     string     `json:"name" validate:"required,min=1,max=100"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+func (cnr CreateNotebookRequest) ToDomain(author uuid.UUID) usecase.CreateNotebookInput {
+	return usecase.CreateNotebookInput{
+		Name:     cnr.Name,
+		ParentID: cnr.ParentID,
+		Author:   author,
+	}
+}
+
+type RenameNotebookRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// MoveNotebookRequest moves a notebook under another one. A null parent_id
+// makes it a top-level notebook.
+type MoveNotebookRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type NotebookIDResponse struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func NewNotebookIDResponse(id uuid.UUID) NotebookIDResponse {
+	return NotebookIDResponse{
+		ID: id,
+	}
+}
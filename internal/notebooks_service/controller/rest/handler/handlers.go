@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/middlewares"
+	"notes-rew/internal/notebooks_service/models"
+	"notes-rew/internal/notebooks_service/usecase"
+	"notes-rew/internal/token_manager"
+)
+
+const userIDKey = "userID"
+
+type NotebookUsecase interface {
+	CreateNotebook(ctx context.Context, req usecase.CreateNotebookInput) (uuid.UUID, error)
+	ReadNotebook(ctx context.Context, id, currentUserID uuid.UUID) (*models.Notebook, error)
+	ReadNotebooks(ctx context.Context, currentUserID uuid.UUID) ([]models.Notebook, error)
+	ReadNotebookContents(ctx context.Context, id, currentUserID uuid.UUID) (*models.NotebookContents, error)
+	RenameNotebook(ctx context.Context, id, currentUserID uuid.UUID, name string) error
+	MoveNotebook(ctx context.Context, id, currentUserID uuid.UUID, parentID *uuid.UUID) error
+	DeleteNotebook(ctx context.Context, id, currentUserID uuid.UUID, mode string) error
+}
+
+type NotebookController struct {
+	usecase      NotebookUsecase
+	validator    *validator.Validate
+	tokenManager *token_manager.TokenManager
+}
+
+func (c *NotebookController) Register(r chi.Router) {
+	scopes := middlewares.ReadWrite(middlewares.ScopeNotesRead, middlewares.ScopeNotesWrite)
+
+	r.Route("/notebooks", func(r chi.Router) {
+		r.Use(middlewares.UserIdentity(c.tokenManager, scopes))
+		r.Post("/", c.CreateNotebookHandler)
+		r.Get("/", c.GetNotebooksHandler)
+		r.Get("/{id}", c.GetNotebookHandler)
+		r.Get("/{id}/contents", c.GetNotebookContentsHandler)
+		r.Patch("/{id}", c.RenameNotebookHandler)
+		r.Post("/{id}/move", c.MoveNotebookHandler)
+		r.Delete("/{id}", c.DeleteNotebookHandler)
+	})
+}
+
+// CreateNotebookHandler
+// @Summary CreateNotebook
+// @Description create notebook, optionally nested in another one
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param notebook body handler.CreateNotebookRequest true "Notebook info"
+// @Success 201
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /notebooks [post]
+func (c *NotebookController) CreateNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	var req CreateNotebookRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	notebookID, err := c.usecase.CreateNotebook(ctx, req.ToDomain(currentUserID))
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(NewNotebookIDResponse(notebookID)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetNotebooksHandler
+// @Summary GetNotebooks
+// @Description list all notebooks of the current user, the tree is rebuilt from parent_id
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Success 200
+// @Failure 500
+// @Router /notebooks [get]
+func (c *NotebookController) GetNotebooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebooks, err := c.usecase.ReadNotebooks(ctx, currentUserID)
+	if err != nil {
+		logrus.Error("error reading notebooks", err)
+		http.Error(w, "failed to retrieve notebooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(notebooks); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetNotebookHandler
+// @Summary GetNotebook
+// @Description get notebook
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Notebook ID"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /notebooks/{id} [get]
+func (c *NotebookController) GetNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid notebook id", http.StatusBadRequest)
+		return
+	}
+
+	notebook, err := c.usecase.ReadNotebook(ctx, notebookID, currentUserID)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(notebook); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetNotebookContentsHandler
+// @Summary GetNotebookContents
+// @Description list the sub-notebooks and notes directly inside a notebook
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Notebook ID"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /notebooks/{id}/contents [get]
+func (c *NotebookController) GetNotebookContentsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid notebook id", http.StatusBadRequest)
+		return
+	}
+
+	contents, err := c.usecase.ReadNotebookContents(ctx, notebookID, currentUserID)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(contents); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RenameNotebookHandler
+// @Summary RenameNotebook
+// @Description rename notebook
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Notebook ID"
+// @Param notebook body handler.RenameNotebookRequest true "New name"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Router /notebooks/{id} [patch]
+func (c *NotebookController) RenameNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid notebook id", http.StatusBadRequest)
+		return
+	}
+
+	var req RenameNotebookRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RenameNotebook(ctx, notebookID, currentUserID, req.Name); err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveNotebookHandler
+// @Summary MoveNotebook
+// @Description move notebook under another notebook, a null parent_id moves it to the top level
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Notebook ID"
+// @Param parent body handler.MoveNotebookRequest true "New parent"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Router /notebooks/{id}/move [post]
+func (c *NotebookController) MoveNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid notebook id", http.StatusBadRequest)
+		return
+	}
+
+	var req MoveNotebookRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.MoveNotebook(ctx, notebookID, currentUserID, req.ParentID); err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteNotebookHandler
+// @Summary DeleteNotebook
+// @Description delete notebook, mode=move (default) hands its contents to the parent, mode=cascade trashes its notes and drops sub-notebooks
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Notebook ID"
+// @Param mode query string false "move or cascade"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Router /notebooks/{id} [delete]
+func (c *NotebookController) DeleteNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid notebook id", http.StatusBadRequest)
+		return
+	}
+
+	err = c.usecase.DeleteNotebook(ctx, notebookID, currentUserID, r.URL.Query().Get("mode"))
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeNotebookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidDeleteMode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrNotebookNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrNotebookCycle):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logrus.Error("error managing notebooks", err)
+		http.Error(w, "failed to process notebook", http.StatusInternalServerError)
+	}
+}
+
+func NewNotebookController(
+	usecase NotebookUsecase,
+	validator *validator.Validate,
+	tokenManager *token_manager.TokenManager,
+) *NotebookController {
+	return &NotebookController{
+		usecase:      usecase,
+		validator:    validator,
+		tokenManager: tokenManager,
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+import "errors"
+
+var (
+	ErrNotebookNotFound = errors.New("notebook not found")
+	ErrNotebookCycle    = errors.New("a notebook can't be moved into itself or its sub-notebooks")
+)
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Notebook struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Author    uuid.UUID  `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NotebookNote is the short form of a note shown in a notebook listing.
+type NotebookNote struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotebookContents holds the direct children of a notebook: its
+// sub-notebooks and the notes filed in it.
+type NotebookContents struct {
+	Notebook  Notebook       `json:"notebook"`
+	Notebooks []Notebook     `json:"notebooks"`
+	Notes     []NotebookNote `json:"notes"`
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateNotebook struct {
+	ID        uuid.UUID
+	Name      string
+	ParentID  *uuid.UUID
+	Author    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/db/transactor"
+	"notes-rew/internal/notebooks_service/models"
+)
+
+type NotebookStorage interface {
+	CreateNotebook(ctx context.Context, notebook CreateNotebook) error
+	GetNotebookByID(ctx context.Context, id uuid.UUID) (models.Notebook, error)
+	GetNotebooksByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Notebook, error)
+	GetChildNotebooks(ctx context.Context, parentID uuid.UUID) ([]models.Notebook, error)
+	GetNotesByNotebookID(ctx context.Context, notebookID uuid.UUID) ([]models.NotebookNote, error)
+	RenameNotebook(ctx context.Context, id uuid.UUID, name string, updatedAt time.Time) error
+	MoveNotebook(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, updatedAt time.Time) error
+	IsDescendant(ctx context.Context, ancestorID, id uuid.UUID) (bool, error)
+	DeleteNotebookMovingContents(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) ([]uuid.UUID, error)
+	DeleteNotebookCascade(ctx context.Context, id uuid.UUID, deletedAt time.Time) ([]uuid.UUID, error)
+}
+
+// NoteCache is the part of the note cache that notebook deletions touch.
+type NoteCache interface {
+	InvalidateNotes(ctx context.Context, authorID uuid.UUID, ids ...uuid.UUID)
+}
+
+type NotebookService struct {
+	storage NotebookStorage
+	cache   NoteCache
+}
+
+func (s *NotebookService) SaveNotebook(ctx context.Context, notebook CreateNotebook) error {
+	return s.storage.CreateNotebook(ctx, notebook)
+}
+
+func (s *NotebookService) GetNotebookByID(ctx context.Context, id uuid.UUID) (models.Notebook, error) {
+	return s.storage.GetNotebookByID(ctx, id)
+}
+
+func (s *NotebookService) GetNotebooksByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Notebook, error) {
+	return s.storage.GetNotebooksByAuthorID(ctx, authorID)
+}
+
+func (s *NotebookService) GetChildNotebooks(ctx context.Context, parentID uuid.UUID) ([]models.Notebook, error) {
+	return s.storage.GetChildNotebooks(ctx, parentID)
+}
+
+func (s *NotebookService) GetNotesByNotebookID(ctx context.Context, notebookID uuid.UUID) ([]models.NotebookNote, error) {
+	return s.storage.GetNotesByNotebookID(ctx, notebookID)
+}
+
+func (s *NotebookService) RenameNotebook(ctx context.Context, id uuid.UUID, name string, updatedAt time.Time) error {
+	return s.storage.RenameNotebook(ctx, id, name, updatedAt)
+}
+
+func (s *NotebookService) MoveNotebook(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, updatedAt time.Time) error {
+	return s.storage.MoveNotebook(ctx, id, parentID, updatedAt)
+}
+
+func (s *NotebookService) IsDescendant(ctx context.Context, ancestorID, id uuid.UUID) (bool, error) {
+	return s.storage.IsDescendant(ctx, ancestorID, id)
+}
+
+func (s *NotebookService) DeleteNotebookMovingContents(
+	ctx context.Context,
+	id, authorID uuid.UUID,
+	parentID *uuid.UUID,
+) error {
+	noteIDs, err := s.storage.DeleteNotebookMovingContents(ctx, id, parentID)
+	if err != nil {
+		return err
+	}
+
+	s.evictNotes(ctx, authorID, noteIDs)
+
+	return nil
+}
+
+func (s *NotebookService) DeleteNotebookCascade(ctx context.Context, id, authorID uuid.UUID, deletedAt time.Time) error {
+	noteIDs, err := s.storage.DeleteNotebookCascade(ctx, id, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	s.evictNotes(ctx, authorID, noteIDs)
+
+	return nil
+}
+
+// evictNotes drops the notes touched by a notebook deletion from the note
+// cache, so that readers don't get their old notebook or trash state.
+func (s *NotebookService) evictNotes(ctx context.Context, authorID uuid.UUID, noteIDs []uuid.UUID) {
+	if len(noteIDs) == 0 {
+		return
+	}
+
+	transactor.AfterCommit(ctx, func() {
+		s.cache.InvalidateNotes(ctx, authorID, noteIDs...)
+	})
+}
+
+func NewNotebookService(storage NotebookStorage, cache NoteCache) *NotebookService {
+	return &NotebookService{
+		storage: storage,
+		cache:   cache,
+	}
+}
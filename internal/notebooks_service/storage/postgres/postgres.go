@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"notes-rew/internal/db/transactor"
+	"notes-rew/internal/notebooks_service/models"
+	"notes-rew/internal/notebooks_service/service"
+)
+
+// notebookTree selects the notebook $1 and all of its sub-notebooks.
+const notebookTree = `WITH RECURSIVE tree AS (
+    SELECT id FROM notebooks WHERE id = $1
+    UNION ALL
+    SELECT n.id FROM notebooks n JOIN tree t ON n.parent_id = t.id
+)`
+
+type NotebookStorage struct {
+	db *pgxpool.Pool
+}
+
+// conn returns the transaction carried by ctx, if any, or the pool.
+func (s *NotebookStorage) conn(ctx context.Context) transactor.Querier {
+	return transactor.Conn(ctx, s.db)
+}
+
+func (s *NotebookStorage) CreateNotebook(ctx context.Context, notebook service.CreateNotebook) error {
+	sql, args, err := squirrel.Insert("notebooks").
+		Columns("id", "name", "parent_id", "author", "created_at", "updated_at").
+		Values(notebook.ID, notebook.Name, notebook.ParentID, notebook.Author, notebook.CreatedAt, notebook.UpdatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NotebookStorage) GetNotebookByID(ctx context.Context, id uuid.UUID) (models.Notebook, error) {
+	var notebook models.Notebook
+
+	sql, args, err := squirrel.Select("id", "name", "parent_id", "author", "created_at", "updated_at").
+		From("notebooks").
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.Notebook{}, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&notebook.ID, &notebook.Name, &notebook.ParentID, &notebook.Author, &notebook.CreatedAt, &notebook.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Notebook{}, models.ErrNotebookNotFound
+		}
+		return models.Notebook{}, err
+	}
+
+	return notebook, nil
+}
+
+func (s *NotebookStorage) GetNotebooksByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Notebook, error) {
+	return s.getNotebooks(ctx, squirrel.Eq{"author": authorID})
+}
+
+func (s *NotebookStorage) GetChildNotebooks(ctx context.Context, parentID uuid.UUID) ([]models.Notebook, error) {
+	return s.getNotebooks(ctx, squirrel.Eq{"parent_id": parentID})
+}
+
+func (s *NotebookStorage) getNotebooks(ctx context.Context, where squirrel.Eq) ([]models.Notebook, error) {
+	sql, args, err := squirrel.Select("id", "name", "parent_id", "author", "created_at", "updated_at").
+		From("notebooks").
+		Where(where).
+		OrderBy("name", "id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notebooks := make([]models.Notebook, 0)
+	for rows.Next() {
+		var notebook models.Notebook
+		err = rows.Scan(
+			&notebook.ID, &notebook.Name, &notebook.ParentID, &notebook.Author, &notebook.CreatedAt, &notebook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notebooks = append(notebooks, notebook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notebooks, nil
+}
+
+func (s *NotebookStorage) GetNotesByNotebookID(ctx context.Context, notebookID uuid.UUID) ([]models.NotebookNote, error) {
+	sql, args, err := squirrel.Select("id", "title", "tags", "updated_at").
+		From("notes").
+		Where(squirrel.Eq{"notebook_id": notebookID, "deleted_at": nil}).
+		OrderBy("updated_at DESC", "id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make([]models.NotebookNote, 0)
+	for rows.Next() {
+		var note models.NotebookNote
+		if err = rows.Scan(&note.ID, &note.Title, &note.Tags, &note.UpdatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+func (s *NotebookStorage) RenameNotebook(ctx context.Context, id uuid.UUID, name string, updatedAt time.Time) error {
+	sql, args, err := squirrel.Update("notebooks").
+		Set("name", name).
+		Set("updated_at", updatedAt).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	return s.execOne(ctx, sql, args)
+}
+
+func (s *NotebookStorage) MoveNotebook(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, updatedAt time.Time) error {
+	sql, args, err := squirrel.Update("notebooks").
+		Set("parent_id", parentID).
+		Set("updated_at", updatedAt).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	return s.execOne(ctx, sql, args)
+}
+
+// IsDescendant reports whether id is ancestorID itself or one of its
+// sub-notebooks at any depth.
+func (s *NotebookStorage) IsDescendant(ctx context.Context, ancestorID, id uuid.UUID) (bool, error) {
+	var found bool
+
+	err := s.conn(ctx).QueryRow(ctx, notebookTree+` SELECT EXISTS (SELECT 1 FROM tree WHERE id = $2)`, ancestorID, id).
+		Scan(&found)
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// DeleteNotebookMovingContents deletes the notebook and hands its notes and
+// sub-notebooks over to parentID, which is nil for the top level. It returns
+// the IDs of the moved notes.
+func (s *NotebookStorage) DeleteNotebookMovingContents(
+	ctx context.Context,
+	id uuid.UUID,
+	parentID *uuid.UUID,
+) ([]uuid.UUID, error) {
+	var noteIDs []uuid.UUID
+
+	err := pgx.BeginFunc(ctx, s.conn(ctx), func(tx pgx.Tx) error {
+		var err error
+
+		noteIDs, err = collectIDs(tx.Query(ctx,
+			`UPDATE notes SET notebook_id = $1, version = version + 1 WHERE notebook_id = $2 RETURNING id`, parentID, id))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE notebooks SET parent_id = $1 WHERE parent_id = $2`, parentID, id)
+		if err != nil {
+			return err
+		}
+
+		return deleteNotebook(ctx, tx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return noteIDs, nil
+}
+
+// DeleteNotebookCascade deletes the notebook with all of its sub-notebooks
+// and moves every note filed in them to the trash. It returns the IDs of the
+// trashed notes.
+func (s *NotebookStorage) DeleteNotebookCascade(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) ([]uuid.UUID, error) {
+	var noteIDs []uuid.UUID
+
+	err := pgx.BeginFunc(ctx, s.conn(ctx), func(tx pgx.Tx) error {
+		var err error
+
+		noteIDs, err = collectIDs(tx.Query(ctx, notebookTree+`
+			UPDATE notes SET deleted_at = $2, version = version + 1
+			WHERE notebook_id IN (SELECT id FROM tree) AND deleted_at IS NULL
+			RETURNING id`, id, deletedAt))
+		if err != nil {
+			return err
+		}
+
+		return deleteNotebook(ctx, tx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return noteIDs, nil
+}
+
+func deleteNotebook(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	tag, err := tx.Exec(ctx, `DELETE FROM notebooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotebookNotFound
+	}
+
+	return nil
+}
+
+func (s *NotebookStorage) execOne(ctx context.Context, sql string, args []interface{}) error {
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotebookNotFound
+	}
+
+	return nil
+}
+
+func collectIDs(rows pgx.Rows, err error) ([]uuid.UUID, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+func NewNotebookStorage(db *pgxpool.Pool) *NotebookStorage {
+	return &NotebookStorage{
+		db: db,
+	}
+}
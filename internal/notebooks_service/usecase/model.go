@@ -0,0 +1,9 @@
+package usecase
+
+import "github.com/google/uuid"
+
+type CreateNotebookInput struct {
+	Name     string
+	ParentID *uuid.UUID
+	Author   uuid.UUID
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notebooks_service/models"
+	"notes-rew/internal/notebooks_service/service"
+)
+
+// Delete modes decide what happens to the contents of a deleted notebook.
+// DeleteModeMove hands notes and sub-notebooks over to the parent notebook
+// (or the top level), DeleteModeCascade moves the whole subtree's notes to
+// the trash and drops the sub-notebooks.
+const (
+	DeleteModeMove    = "move"
+	DeleteModeCascade = "cascade"
+)
+
+var ErrInvalidDeleteMode = errors.New("delete mode must be move or cascade")
+
+type NotebookService interface {
+	SaveNotebook(ctx context.Context, notebook service.CreateNotebook) error
+	GetNotebookByID(ctx context.Context, id uuid.UUID) (models.Notebook, error)
+	GetNotebooksByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Notebook, error)
+	GetChildNotebooks(ctx context.Context, parentID uuid.UUID) ([]models.Notebook, error)
+	GetNotesByNotebookID(ctx context.Context, notebookID uuid.UUID) ([]models.NotebookNote, error)
+	RenameNotebook(ctx context.Context, id uuid.UUID, name string, updatedAt time.Time) error
+	MoveNotebook(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, updatedAt time.Time) error
+	IsDescendant(ctx context.Context, ancestorID, id uuid.UUID) (bool, error)
+	DeleteNotebookMovingContents(ctx context.Context, id, authorID uuid.UUID, parentID *uuid.UUID) error
+	DeleteNotebookCascade(ctx context.Context, id, authorID uuid.UUID, deletedAt time.Time) error
+}
+
+type NotebookUsecase struct {
+	service NotebookService
+}
+
+func (u *NotebookUsecase) CreateNotebook(ctx context.Context, req CreateNotebookInput) (uuid.UUID, error) {
+	if req.ParentID != nil {
+		if _, err := u.readOwned(ctx, *req.ParentID, req.Author); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	notebook := service.CreateNotebook{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		ParentID:  req.ParentID,
+		Author:    req.Author,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := u.service.SaveNotebook(ctx, notebook); err != nil {
+		return uuid.Nil, err
+	}
+
+	return notebook.ID, nil
+}
+
+func (u *NotebookUsecase) ReadNotebook(ctx context.Context, id, currentUserID uuid.UUID) (*models.Notebook, error) {
+	return u.readOwned(ctx, id, currentUserID)
+}
+
+func (u *NotebookUsecase) ReadNotebooks(ctx context.Context, currentUserID uuid.UUID) ([]models.Notebook, error) {
+	return u.service.GetNotebooksByAuthorID(ctx, currentUserID)
+}
+
+func (u *NotebookUsecase) ReadNotebookContents(
+	ctx context.Context,
+	id, currentUserID uuid.UUID,
+) (*models.NotebookContents, error) {
+	notebook, err := u.readOwned(ctx, id, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := u.service.GetChildNotebooks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := u.service.GetNotesByNotebookID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotebookContents{
+		Notebook:  *notebook,
+		Notebooks: children,
+		Notes:     notes,
+	}, nil
+}
+
+func (u *NotebookUsecase) RenameNotebook(ctx context.Context, id, currentUserID uuid.UUID, name string) error {
+	if _, err := u.readOwned(ctx, id, currentUserID); err != nil {
+		return err
+	}
+
+	return u.service.RenameNotebook(ctx, id, name, time.Now().UTC())
+}
+
+// MoveNotebook makes the notebook a child of parentID, or a top-level notebook
+// when parentID is nil. Moving a notebook under itself is rejected.
+func (u *NotebookUsecase) MoveNotebook(ctx context.Context, id, currentUserID uuid.UUID, parentID *uuid.UUID) error {
+	if _, err := u.readOwned(ctx, id, currentUserID); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if _, err := u.readOwned(ctx, *parentID, currentUserID); err != nil {
+			return err
+		}
+
+		cycle, err := u.service.IsDescendant(ctx, id, *parentID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return models.ErrNotebookCycle
+		}
+	}
+
+	return u.service.MoveNotebook(ctx, id, parentID, time.Now().UTC())
+}
+
+func (u *NotebookUsecase) DeleteNotebook(ctx context.Context, id, currentUserID uuid.UUID, mode string) error {
+	if mode == "" {
+		mode = DeleteModeMove
+	}
+
+	if mode != DeleteModeMove && mode != DeleteModeCascade {
+		return ErrInvalidDeleteMode
+	}
+
+	notebook, err := u.readOwned(ctx, id, currentUserID)
+	if err != nil {
+		return err
+	}
+
+	if mode == DeleteModeCascade {
+		return u.service.DeleteNotebookCascade(ctx, id, notebook.Author, time.Now().UTC())
+	}
+
+	return u.service.DeleteNotebookMovingContents(ctx, id, notebook.Author, notebook.ParentID)
+}
+
+// readOwned returns the notebook if it belongs to the current user. Other
+// users' notebooks are reported as missing.
+func (u *NotebookUsecase) readOwned(ctx context.Context, id, currentUserID uuid.UUID) (*models.Notebook, error) {
+	notebook, err := u.service.GetNotebookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if notebook.Author != currentUserID {
+		return nil, models.ErrNotebookNotFound
+	}
+
+	return &notebook, nil
+}
+
+func NewNotebookUsecase(service NotebookService) *NotebookUsecase {
+	return &NotebookUsecase{
+		service: service,
+	}
+}
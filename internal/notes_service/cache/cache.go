@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+	"notes-rew/internal/notes_service/models"
+)
+
+const (
+	keyPrefix = "notes"
+	// schemaVersion is bumped whenever the layout of the cached JSON changes,
+	// so that entries written by an older release are never decoded.
+	schemaVersion = "v2"
+
+	// retryAfter is how long Redis is left alone after it failed. Meanwhile
+	// every read goes straight to the database.
+	retryAfter = 10 * time.Second
+)
+
+// NoteCache is a cache-aside layer over Redis for single notes, their
+// renderings and the author's note lists.
+//
+// A list key carries the author's list generation: any change to one of the
+// author's notes bumps it, so all of their cached lists go stale at once and
+// simply expire. Notes have a generation too, which keeps a read that raced
+// with a change from caching the note as it was before.
+//
+// The cache never fails a read. With a nil client, or while Redis is down,
+// it hands every call to the loader.
+type NoteCache struct {
+	client  *redis.Client
+	noteTTL time.Duration
+	listTTL time.Duration
+	group   singleflight.Group
+	// downUntil is the unix nano time until which Redis is skipped.
+	downUntil atomic.Int64
+}
+
+func noteKey(id uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:note:%s", keyPrefix, schemaVersion, id)
+}
+
+func renderedKey(id uuid.UUID, version int64) string {
+	return fmt.Sprintf("%s:%s:rendered:%s:%d", keyPrefix, schemaVersion, id, version)
+}
+
+func noteGenerationKey(id uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:note_gen:%s", keyPrefix, schemaVersion, id)
+}
+
+func listGenerationKey(authorID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:list_gen:%s", keyPrefix, schemaVersion, authorID)
+}
+
+func listKey(authorID uuid.UUID, generation int64, query string) string {
+	return fmt.Sprintf("%s:%s:list:%s:%d:%s", keyPrefix, schemaVersion, authorID, generation, query)
+}
+
+// GetNote returns the cached note or loads it. Concurrent misses on the same
+// note share a single load.
+func (c *NoteCache) GetNote(
+	ctx context.Context,
+	id uuid.UUID,
+	load func(ctx context.Context) (models.NoteOutput, error),
+) (models.NoteOutput, error) {
+	key := noteKey(id)
+
+	var note models.NoteOutput
+	if c.get(ctx, key, &note) {
+		return note, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		// The generation is read before loading: if the note changes while
+		// it loads, the load may return the old state, which must not be
+		// cached.
+		generation, ok := c.generation(ctx, noteGenerationKey(id))
+
+		note, err := load(ctx)
+		if err != nil {
+			return note, err
+		}
+
+		if ok {
+			c.setUnlessChanged(ctx, key, noteGenerationKey(id), generation, note, c.noteTTL)
+		}
+
+		return note, nil
+	})
+
+	return v.(models.NoteOutput), err
+}
+
+// SetNote writes a note through to the cache.
+func (c *NoteCache) SetNote(ctx context.Context, note models.NoteOutput) {
+	c.set(ctx, noteKey(note.ID), note, c.noteTTL)
+}
+
+// GetRenderedNote returns the cached rendering of a version of the note or
+// renders it. A version never changes, so the entry needs no invalidation:
+// renderings of old versions just expire.
+func (c *NoteCache) GetRenderedNote(
+	ctx context.Context,
+	id uuid.UUID,
+	version int64,
+	render func(ctx context.Context) (models.RenderedNote, error),
+) (models.RenderedNote, error) {
+	key := renderedKey(id, version)
+
+	var rendered models.RenderedNote
+	if c.get(ctx, key, &rendered) {
+		return rendered, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		rendered, err := render(ctx)
+		if err != nil {
+			return rendered, err
+		}
+
+		c.set(ctx, key, rendered, c.noteTTL)
+
+		return rendered, nil
+	})
+
+	return v.(models.RenderedNote), err
+}
+
+// GetNotes returns a cached list of the author's notes or loads it. query
+// must identify the list among the author's lists.
+func (c *NoteCache) GetNotes(
+	ctx context.Context,
+	authorID uuid.UUID,
+	query string,
+	load func(ctx context.Context) ([]models.NoteOutput, error),
+) ([]models.NoteOutput, error) {
+	generation, ok := c.listGeneration(ctx, authorID)
+	if !ok {
+		return load(ctx)
+	}
+
+	key := listKey(authorID, generation, query)
+
+	var notes []models.NoteOutput
+	if c.get(ctx, key, &notes) {
+		return notes, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		notes, err := load(ctx)
+		if err != nil {
+			return notes, err
+		}
+
+		c.set(ctx, key, notes, c.listTTL)
+
+		return notes, nil
+	})
+
+	notes, _ = v.([]models.NoteOutput)
+
+	return notes, err
+}
+
+// InvalidateNotes drops the given notes and every list of their author.
+// Unlike reads it is tried even while Redis is being skipped, so that no
+// stale entry outlives an outage.
+func (c *NoteCache) InvalidateNotes(ctx context.Context, authorID uuid.UUID, ids ...uuid.UUID) {
+	if c.client == nil {
+		return
+	}
+
+	pipe := c.client.TxPipeline()
+
+	if len(ids) > 0 {
+		keys := make([]string, 0, len(ids))
+		for _, id := range ids {
+			keys = append(keys, noteKey(id))
+			// The generation only has to outlive the loads that started
+			// before it was bumped.
+			pipe.Incr(ctx, noteGenerationKey(id))
+			pipe.Expire(ctx, noteGenerationKey(id), c.noteTTL)
+		}
+		pipe.Del(ctx, keys...)
+	}
+
+	pipe.Incr(ctx, listGenerationKey(authorID))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.fail("invalidating notes", err)
+	}
+}
+
+func (c *NoteCache) listGeneration(ctx context.Context, authorID uuid.UUID) (int64, bool) {
+	return c.generation(ctx, listGenerationKey(authorID))
+}
+
+func (c *NoteCache) generation(ctx context.Context, key string) (int64, bool) {
+	if !c.available() {
+		return 0, false
+	}
+
+	generation, err := c.client.Get(ctx, key).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.fail("reading generation", err)
+		return 0, false
+	}
+
+	return generation, true
+}
+
+func (c *NoteCache) get(ctx context.Context, key string, dst interface{}) bool {
+	if !c.available() {
+		return false
+	}
+
+	cached, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.fail("reading from Redis", err)
+		}
+		return false
+	}
+
+	if err = json.Unmarshal(cached, dst); err != nil {
+		logrus.Printf("error while unmarshaling cached data: %v", err)
+		return false
+	}
+
+	return true
+}
+
+func (c *NoteCache) set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if !c.available() {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logrus.Printf("error while marshaling data for Redis: %v", err)
+		return
+	}
+
+	if err = c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		c.fail("saving to Redis", err)
+	}
+}
+
+// setUnlessChanged is set for a value loaded after generationKey held
+// generation. If the generation moved on meanwhile, the value is dropped.
+func (c *NoteCache) setUnlessChanged(
+	ctx context.Context,
+	key, generationKey string,
+	generation int64,
+	value interface{},
+	ttl time.Duration,
+) {
+	if !c.available() {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logrus.Printf("error while marshaling data for Redis: %v", err)
+		return
+	}
+
+	// WATCH fails the write if the generation is bumped between the check
+	// and the SET.
+	err = c.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, generationKey).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		if current != generation {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+
+		return err
+	}, generationKey)
+
+	if err != nil && !errors.Is(err, redis.TxFailedErr) {
+		c.fail("saving to Redis", err)
+	}
+}
+
+func (c *NoteCache) available() bool {
+	return c.client != nil && time.Now().UnixNano() >= c.downUntil.Load()
+}
+
+func (c *NoteCache) fail(action string, err error) {
+	// A cancelled request says nothing about the health of Redis.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	logrus.Printf("error while %s, skipping the note cache for %s: %v", action, retryAfter, err)
+	c.downUntil.Store(time.Now().Add(retryAfter).UnixNano())
+}
+
+func NewNoteCache(client *redis.Client, noteTTL, listTTL time.Duration) *NoteCache {
+	return &NoteCache{
+		client:  client,
+		noteTTL: noteTTL,
+		listTTL: listTTL,
+	}
+}
@@ -2,6 +2,8 @@ package v1
 
 import (
 	"context"
+	"errors"
+
 	pb_notes_model "github.com/almalii/grpc-contracts/gen/go/notes_service/model/v1"
 	pb_notes_service "github.com/almalii/grpc-contracts/gen/go/notes_service/service/v1"
 	"github.com/go-playground/validator/v10"
@@ -19,9 +21,9 @@ const userIDKey = "userID"
 type NoteUsecase interface {
 	CreateNote(ctx context.Context, req usecase.CreateNoteInput) (uuid.UUID, error)
 	ReadNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.NoteOutput, error)
-	ReadAllNotes(ctx context.Context, currentUserID uuid.UUID) ([]models.NoteOutput, error)
-	UpdateNote(ctx context.Context, id uuid.UUID, req usecase.UpdateNoteInput) error
-	DeleteNote(ctx context.Context, id uuid.UUID) error
+	ReadAllNotes(ctx context.Context, currentUserID uuid.UUID, req usecase.ListNotesInput) (*models.NotesPage, error)
+	UpdateNote(ctx context.Context, id, currentUserID uuid.UUID, req usecase.UpdateNoteInput) (int64, error)
+	DeleteNote(ctx context.Context, id, currentUserID uuid.UUID) error
 }
 
 type NotesServer struct {
@@ -50,6 +52,9 @@ func (n *NotesServer) CreateNote(
 
 	noteID, err := n.usecase.CreateNote(ctx, input)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailNotVerified) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		logrus.Error("error creating note: ", err)
 		return nil, status.Error(codes.Internal, "error creating note")
 	}
@@ -94,10 +99,25 @@ func (n *NotesServer) GetNotes(
 		return nil, status.Error(codes.Internal, "error getting user id")
 	}
 
-	notes, err := n.usecase.ReadAllNotes(ctx, currentUserID)
-	if err != nil {
-		logrus.Error("error getting notes: ", err)
-		return nil, status.Error(codes.Internal, "error getting notes")
+	// The v1 contract has no paging fields, so it walks every page and
+	// returns all notes at once. Clients that want pages should use ListNotes
+	// from the v2 NotesService.
+	var notes []models.NoteOutput
+
+	input := usecase.ListNotesInput{Limit: usecase.MaxNotesLimit}
+	for {
+		page, err := n.usecase.ReadAllNotes(ctx, currentUserID, input)
+		if err != nil {
+			logrus.Error("error getting notes: ", err)
+			return nil, status.Error(codes.Internal, "error getting notes")
+		}
+
+		notes = append(notes, page.Notes...)
+
+		if page.NextCursor == "" {
+			break
+		}
+		input.Cursor = page.NextCursor
 	}
 
 	resp := NewGetNotesResponse(notes)
@@ -130,8 +150,12 @@ func (n *NotesServer) UpdateNote(
 		return nil, status.Error(codes.Internal, "error getting note")
 	}
 
-	err = n.usecase.UpdateNote(ctx, noteID, input)
+	_, err = n.usecase.UpdateNote(ctx, noteID, currentUserID, input)
 	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
 		logrus.Error("error updating note: ", err)
 		return nil, status.Error(codes.Internal, "error updating note")
 	}
@@ -160,8 +184,12 @@ func (n *NotesServer) DeleteNote(
 		return nil, status.Error(codes.Internal, "error getting note")
 	}
 
-	err = n.usecase.DeleteNote(ctx, noteID)
+	err = n.usecase.DeleteNote(ctx, noteID, currentUserID)
 	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
 		logrus.Error("error deleting note: ", err)
 		return nil, status.Error(codes.Internal, "error deleting note")
 	}
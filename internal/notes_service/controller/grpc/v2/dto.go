@@ -0,0 +1,190 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb_notes_model "notes-rew/api/gen/go/notes_service/model/v2"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+func NewNote(note models.NoteOutput) *pb_notes_model.Note {
+	var notebookID string
+	if note.NotebookID != nil {
+		notebookID = note.NotebookID.String()
+	}
+
+	return &pb_notes_model.Note{
+		Id:         note.ID.String(),
+		Title:      note.Title,
+		Body:       note.Body,
+		Tags:       note.Tags,
+		Author:     note.Author.String(),
+		CreatedAt:  timestamppb.New(note.CreatedAt),
+		UpdatedAt:  timestamppb.New(note.UpdatedAt),
+		NotebookId: notebookID,
+		Version:    note.Version,
+	}
+}
+
+func NewRenderedNote(rendered models.RenderedNote) *pb_notes_model.RenderedNote {
+	toc := make([]*pb_notes_model.Heading, 0, len(rendered.TOC))
+	for _, heading := range rendered.TOC {
+		toc = append(toc, &pb_notes_model.Heading{
+			Level: int32(heading.Level),
+			Text:  heading.Text,
+			Id:    heading.ID,
+		})
+	}
+
+	return &pb_notes_model.RenderedNote{
+		Id:      rendered.ID.String(),
+		Title:   rendered.Title,
+		Version: rendered.Version,
+		Html:    rendered.HTML,
+		Toc:     toc,
+	}
+}
+
+func NewNoteEvent(event models.NoteEvent) *pb_notes_model.NoteEvent {
+	return &pb_notes_model.NoteEvent{
+		Id:         event.ID.String(),
+		Type:       event.Type,
+		NoteId:     event.NoteID.String(),
+		Version:    event.Version,
+		ActorId:    event.ActorID.String(),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
+
+func NewUpdateNoteInput(req *pb_notes_model.UpdateNoteRequest) usecase.UpdateNoteInput {
+	input := usecase.UpdateNoteInput{
+		Title: &req.Title,
+		Body:  &req.Body,
+		Tags:  &req.Tags,
+	}
+
+	if req.ExpectedVersion != 0 {
+		input.ExpectedVersion = &req.ExpectedVersion
+	}
+
+	return input
+}
+
+var errEmptyUpdateMask = errors.New("update_mask must name at least one field")
+
+// NewPatchNoteInput keeps only the fields named in the update mask, so that
+// the others are left untouched.
+func NewPatchNoteInput(req *pb_notes_model.PatchNoteRequest) (usecase.UpdateNoteInput, error) {
+	var input usecase.UpdateNoteInput
+
+	if req.ExpectedVersion != 0 {
+		input.ExpectedVersion = &req.ExpectedVersion
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return input, errEmptyUpdateMask
+	}
+
+	patch := req.GetNote()
+	if patch == nil {
+		patch = &pb_notes_model.NotePatch{}
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "title":
+			if patch.Title == "" {
+				return input, fmt.Errorf("title can't be empty")
+			}
+			input.Title = &patch.Title
+		case "body":
+			input.Body = &patch.Body
+		case "tags":
+			tags := patch.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			input.Tags = &tags
+		default:
+			return input, fmt.Errorf("field %q can't be updated", path)
+		}
+	}
+
+	return input, nil
+}
+
+func NewListNotesInput(req *pb_notes_model.ListNotesRequest) usecase.ListNotesInput {
+	input := usecase.ListNotesInput{
+		Limit:  int(req.Limit),
+		Cursor: req.Cursor,
+		SortBy: req.SortBy,
+		Order:  req.Order,
+		Tags:   req.Tags,
+	}
+
+	if req.CreatedFrom != nil {
+		from := req.CreatedFrom.AsTime()
+		input.CreatedFrom = &from
+	}
+
+	if req.CreatedTo != nil {
+		to := req.CreatedTo.AsTime()
+		input.CreatedTo = &to
+	}
+
+	return input
+}
+
+func NewListNotesResponse(page *models.NotesPage) *pb_notes_model.ListNotesResponse {
+	notes := make([]*pb_notes_model.Note, 0, len(page.Notes))
+	for _, note := range page.Notes {
+		notes = append(notes, NewNote(note))
+	}
+
+	return &pb_notes_model.ListNotesResponse{
+		Notes:      notes,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func NewSearchNotesInput(req *pb_notes_model.SearchNotesRequest) usecase.SearchNotesInput {
+	return usecase.SearchNotesInput{
+		Query:  req.Query,
+		Limit:  int(req.Limit),
+		Offset: int(req.Offset),
+	}
+}
+
+func NewSearchNotesResponse(results []models.NoteSearchResult) *pb_notes_model.SearchNotesResponse {
+	resp := make([]*pb_notes_model.SearchResult, 0, len(results))
+	for _, result := range results {
+		resp = append(resp, &pb_notes_model.SearchResult{
+			Note:    NewNote(result.NoteOutput),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+
+	return &pb_notes_model.SearchNotesResponse{
+		Results: resp,
+	}
+}
+
+// ParseOptionalID parses an ID field where the empty string stands for "none".
+func ParseOptionalID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
@@ -0,0 +1,320 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	pb_notes_model "notes-rew/api/gen/go/notes_service/model/v2"
+	pb_notes_service "notes-rew/api/gen/go/notes_service/service/v2"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+const userIDKey = "userID"
+
+type NoteUsecase interface {
+	ReadNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.NoteOutput, error)
+	RenderNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.RenderedNote, error)
+	UpdateNote(ctx context.Context, id, currentUserID uuid.UUID, req usecase.UpdateNoteInput) (int64, error)
+	ReadAllNotes(ctx context.Context, currentUserID uuid.UUID, req usecase.ListNotesInput) (*models.NotesPage, error)
+	SearchNotes(ctx context.Context, currentUserID uuid.UUID, req usecase.SearchNotesInput) ([]models.NoteSearchResult, error)
+	MoveNote(ctx context.Context, noteID, currentUserID uuid.UUID, notebookID *uuid.UUID) error
+	WatchNotes(ctx context.Context, currentUserID uuid.UUID) (<-chan models.NoteEvent, func())
+}
+
+type NotesServer struct {
+	usecase NoteUsecase
+	pb_notes_service.UnimplementedNotesServiceServer
+}
+
+func (n *NotesServer) ListNotes(
+	ctx context.Context,
+	req *pb_notes_model.ListNotesRequest,
+) (*pb_notes_model.ListNotesResponse, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	page, err := n.usecase.ReadAllNotes(ctx, currentUserID, NewListNotesInput(req))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidCursor) ||
+			errors.Is(err, usecase.ErrInvalidSort) ||
+			errors.Is(err, usecase.ErrInvalidOrder) ||
+			errors.Is(err, usecase.ErrInvalidLimit) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		logrus.Error("error listing notes: ", err)
+		return nil, status.Error(codes.Internal, "error listing notes")
+	}
+
+	resp := NewListNotesResponse(page)
+
+	return resp, nil
+}
+
+func (n *NotesServer) SearchNotes(
+	ctx context.Context,
+	req *pb_notes_model.SearchNotesRequest,
+) (*pb_notes_model.SearchNotesResponse, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	results, err := n.usecase.SearchNotes(ctx, currentUserID, NewSearchNotesInput(req))
+	if err != nil {
+		if errors.Is(err, usecase.ErrEmptySearchQuery) ||
+			errors.Is(err, usecase.ErrInvalidLimit) ||
+			errors.Is(err, usecase.ErrInvalidOffset) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		logrus.Error("error searching notes: ", err)
+		return nil, status.Error(codes.Internal, "error searching notes")
+	}
+
+	resp := NewSearchNotesResponse(results)
+
+	return resp, nil
+}
+
+func (n *NotesServer) GetNote(
+	ctx context.Context,
+	req *pb_notes_model.GetNoteRequest,
+) (*pb_notes_model.Note, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	noteID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid note id")
+	}
+
+	note, err := n.usecase.ReadNote(ctx, noteID, currentUserID)
+	if err != nil {
+		logrus.Error("error getting note: ", err)
+		return nil, status.Error(codes.NotFound, "note not found")
+	}
+
+	return NewNote(*note), nil
+}
+
+func (n *NotesServer) RenderNote(
+	ctx context.Context,
+	req *pb_notes_model.RenderNoteRequest,
+) (*pb_notes_model.RenderedNote, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	noteID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid note id")
+	}
+
+	rendered, err := n.usecase.RenderNote(ctx, noteID, currentUserID)
+	if err != nil {
+		logrus.Error("error rendering note: ", err)
+		return nil, status.Error(codes.NotFound, "note not found")
+	}
+
+	return NewRenderedNote(*rendered), nil
+}
+
+func (n *NotesServer) UpdateNote(
+	ctx context.Context,
+	req *pb_notes_model.UpdateNoteRequest,
+) (*pb_notes_model.Note, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	noteID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid note id")
+	}
+
+	if req.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	if _, err = n.usecase.UpdateNote(ctx, noteID, currentUserID, NewUpdateNoteInput(req)); err != nil {
+		return nil, updateNoteError(err)
+	}
+
+	note, err := n.usecase.ReadNote(ctx, noteID, currentUserID)
+	if err != nil {
+		logrus.Error("error getting note: ", err)
+		return nil, status.Error(codes.Internal, "error getting note")
+	}
+
+	return NewNote(*note), nil
+}
+
+func (n *NotesServer) PatchNote(
+	ctx context.Context,
+	req *pb_notes_model.PatchNoteRequest,
+) (*pb_notes_model.Note, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	noteID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid note id")
+	}
+
+	input, err := NewPatchNoteInput(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if _, err = n.usecase.UpdateNote(ctx, noteID, currentUserID, input); err != nil {
+		return nil, updateNoteError(err)
+	}
+
+	note, err := n.usecase.ReadNote(ctx, noteID, currentUserID)
+	if err != nil {
+		logrus.Error("error getting note: ", err)
+		return nil, status.Error(codes.Internal, "error getting note")
+	}
+
+	return NewNote(*note), nil
+}
+
+// updateNoteError maps a version conflict to FAILED_PRECONDITION with the
+// current version in an ErrorInfo detail, so that clients can refetch.
+func updateNoteError(err error) error {
+	var conflict *models.VersionConflictError
+
+	switch {
+	case errors.As(err, &conflict):
+		st := status.New(codes.FailedPrecondition, conflict.Error())
+
+		detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason: "NOTE_VERSION_MISMATCH",
+			Domain: "notes_service",
+			Metadata: map[string]string{
+				"current_version": strconv.FormatInt(conflict.CurrentVersion, 10),
+			},
+		})
+		if detailErr != nil {
+			return st.Err()
+		}
+
+		return detailed.Err()
+	case errors.Is(err, models.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, models.ErrNoteNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	logrus.Error("error updating note: ", err)
+	return status.Error(codes.Internal, "error updating note")
+}
+
+func (n *NotesServer) MoveNote(
+	ctx context.Context,
+	req *pb_notes_model.MoveNoteRequest,
+) (*emptypb.Empty, error) {
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return nil, status.Error(codes.Internal, "error getting user id")
+	}
+
+	noteID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid note id")
+	}
+
+	notebookID, err := ParseOptionalID(req.NotebookId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid notebook id")
+	}
+
+	if err = n.usecase.MoveNote(ctx, noteID, currentUserID, notebookID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrForbidden):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, models.ErrNotebookNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		logrus.Error("error moving note: ", err)
+		return nil, status.Error(codes.NotFound, "note not found")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// WatchNotes streams the events of the notes the caller can see until the
+// call is cancelled. A caller too slow to keep up gets Unavailable and
+// should watch again after reading its notes.
+func (n *NotesServer) WatchNotes(
+	_ *pb_notes_model.WatchNotesRequest,
+	stream pb_notes_service.NotesService_WatchNotesServer,
+) error {
+	ctx := stream.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error getting user id from context")
+		return status.Error(codes.Internal, "error getting user id")
+	}
+
+	events, unsubscribe := n.usecase.WatchNotes(ctx, currentUserID)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "too far behind, watch again")
+			}
+
+			if err := stream.Send(NewNoteEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func NewNotesServer(
+	usecase NoteUsecase,
+	unimplementedNotesServiceServer pb_notes_service.UnimplementedNotesServiceServer,
+) *NotesServer {
+	return &NotesServer{
+		usecase:                         usecase,
+		UnimplementedNotesServiceServer: unimplementedNotesServiceServer,
+	}
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/blobstore"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+const (
+	attachmentFormField = "file"
+	// multipartOverhead leaves room for the part headers and boundaries of
+	// an upload on top of the file itself.
+	multipartOverhead = 64 << 10
+	// downloadTimeout is how long sending an attachment may take, in place
+	// of the server's write timeout.
+	downloadTimeout = 10 * time.Minute
+)
+
+// UploadAttachmentHandler
+// @Summary UploadAttachment
+// @Description attach an image or a PDF file to a note, sent as the "file" field of a multipart form
+// @Security JWTAuth
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param file formData file true "Image or PDF file"
+// @Success 201
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 413
+// @Failure 415
+// @Failure 507
+// @Router /notes/{id}/attachments [post]
+func (c *NoteController) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	extendDeadlines(w, uploadTimeout)
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.limits.MaxAttachmentSize+multipartOverhead)
+
+	// The file is streamed to the blob store part by part instead of being
+	// buffered by ParseMultipartForm.
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	part, err := nextFilePart(reader)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	defer part.Close()
+
+	attachment, err := c.usecase.AddAttachment(ctx, noteID, currentUserID, usecase.AddAttachmentInput{
+		Filename: part.FileName(),
+		Content:  part,
+	})
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(attachment); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+var errNoAttachmentFile = errors.New(`multipart form has no "file" field`)
+
+// nextFilePart skips to the part holding the file.
+func nextFilePart(reader *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errNoAttachmentFile
+			}
+			return nil, err
+		}
+
+		if part.FormName() == attachmentFormField {
+			return part, nil
+		}
+
+		part.Close()
+	}
+}
+
+// GetAttachmentsHandler
+// @Summary GetAttachments
+// @Description list the files attached to a note
+// @Security JWTAuth
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /notes/{id}/attachments [get]
+func (c *NoteController) GetAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := c.usecase.ReadAttachments(ctx, noteID, currentUserID)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(attachments); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DownloadAttachmentHandler
+// @Summary DownloadAttachment
+// @Description download an attached file, byte ranges are supported
+// @Security JWTAuth
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Note ID"
+// @Param attachmentID path string true "Attachment ID"
+// @Success 200
+// @Success 206
+// @Failure 400
+// @Failure 404
+// @Failure 416
+// @Router /notes/{id}/attachments/{attachmentID} [get]
+func (c *NoteController) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid attachment id", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := c.usecase.OpenAttachment(ctx, noteID, attachmentID, currentUserID)
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	defer content.Close()
+
+	// Images open in the browser, anything else is saved. The sniffed type
+	// is final: the browser must not guess another one.
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{
+		"filename": attachment.Filename,
+	}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	extendDeadlines(w, downloadTimeout)
+
+	// ServeContent answers range and conditional requests on its own.
+	http.ServeContent(w, r, attachment.Filename, attachment.CreatedAt, content)
+}
+
+// DeleteAttachmentHandler
+// @Summary DeleteAttachment
+// @Description delete an attached file
+// @Security JWTAuth
+// @Tags attachments
+// @Param id path string true "Note ID"
+// @Param attachmentID path string true "Attachment ID"
+// @Success 204
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/attachments/{attachmentID} [delete]
+func (c *NoteController) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid attachment id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.DeleteAttachment(ctx, noteID, attachmentID, currentUserID); err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAttachmentError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, errNoAttachmentFile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, usecase.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, usecase.ErrUnsupportedAttachmentType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, usecase.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	case errors.Is(err, models.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, models.ErrAttachmentNotFound), errors.Is(err, blobstore.ErrBlobNotFound):
+		http.Error(w, "attachment is not found", http.StatusNotFound)
+	default:
+		logrus.Error("error managing attachments", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+	}
+}
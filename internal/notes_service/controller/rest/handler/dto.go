@@ -1,23 +1,31 @@
 package handler
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
 	"notes-rew/internal/notes_service/usecase"
-	"time"
 )
 
 type CreateNoteRequest struct {
-	Title string   `json:"title" validate:"required,alphanum,min=1,max=50"`
-	Body  string   `json:"body" validate:"required,bytesize"`
-	Tags  []string `json:"tags" validate:"omitempty"`
+	Title      string     `json:"title" validate:"required,alphanum,min=1,max=50"`
+	Body       string     `json:"body" validate:"required,bytesize"`
+	Tags       []string   `json:"tags" validate:"omitempty"`
+	NotebookID *uuid.UUID `json:"notebook_id"`
 }
 
 func (cnr CreateNoteRequest) ToDomain(uuid uuid.UUID) usecase.CreateNoteInput {
 	return usecase.CreateNoteInput{
-		Title:  cnr.Title,
-		Body:   cnr.Body,
-		Tags:   cnr.Tags,
-		Author: uuid,
+		Title:      cnr.Title,
+		Body:       cnr.Body,
+		Tags:       cnr.Tags,
+		Author:     uuid,
+		NotebookID: cnr.NotebookID,
 	}
 }
 
@@ -36,6 +44,18 @@ func (unr UpdateNoteRequest) ToDomain() usecase.UpdateNoteInput {
 	}
 }
 
+type VersionConflictResponse struct {
+	Error          string `json:"error"`
+	CurrentVersion int64  `json:"current_version"`
+}
+
+func NewVersionConflictResponse(conflict *models.VersionConflictError) *VersionConflictResponse {
+	return &VersionConflictResponse{
+		Error:          models.ErrVersionMismatch.Error(),
+		CurrentVersion: conflict.CurrentVersion,
+	}
+}
+
 type NoteResponse struct {
 	ID    uuid.UUID `json:"id"`
 	Title string    `json:"title"`
@@ -45,3 +65,233 @@ type NoteResponse struct {
 func NewNoteResponse(ID uuid.UUID, title string, body string) *NoteResponse {
 	return &NoteResponse{ID: ID, Title: title, Body: body}
 }
+
+// NewListNotesInput reads the paging, sorting and filtering parameters of GET /notes.
+func NewListNotesInput(query url.Values) (usecase.ListNotesInput, error) {
+	input := usecase.ListNotesInput{
+		Cursor: query.Get("cursor"),
+		SortBy: query.Get("sort"),
+		Order:  query.Get("order"),
+	}
+
+	limit, err := parseIntParam(query, "limit")
+	if err != nil {
+		return usecase.ListNotesInput{}, err
+	}
+
+	input.Limit = limit
+
+	for _, tags := range query["tag"] {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				input.Tags = append(input.Tags, tag)
+			}
+		}
+	}
+
+	from, err := parseTimeParam(query, "created_from")
+	if err != nil {
+		return usecase.ListNotesInput{}, err
+	}
+
+	to, err := parseTimeParam(query, "created_to")
+	if err != nil {
+		return usecase.ListNotesInput{}, err
+	}
+
+	input.CreatedFrom, input.CreatedTo = from, to
+
+	return input, nil
+}
+
+type SearchNotesRequest struct {
+	Query  string `json:"q" validate:"required,max=256"`
+	Limit  int    `json:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `json:"offset" validate:"min=0"`
+}
+
+func NewSearchNotesRequest(query url.Values) (SearchNotesRequest, error) {
+	req := SearchNotesRequest{
+		Query: strings.TrimSpace(query.Get("q")),
+	}
+
+	limit, err := parseIntParam(query, "limit")
+	if err != nil {
+		return SearchNotesRequest{}, err
+	}
+
+	offset, err := parseIntParam(query, "offset")
+	if err != nil {
+		return SearchNotesRequest{}, err
+	}
+
+	req.Limit, req.Offset = limit, offset
+
+	return req, nil
+}
+
+func (snr SearchNotesRequest) ToDomain() usecase.SearchNotesInput {
+	return usecase.SearchNotesInput{
+		Query:  snr.Query,
+		Limit:  snr.Limit,
+		Offset: snr.Offset,
+	}
+}
+
+type SearchNotesResponse struct {
+	Results []models.NoteSearchResult `json:"results"`
+}
+
+func NewSearchNotesResponse(results []models.NoteSearchResult) SearchNotesResponse {
+	return SearchNotesResponse{Results: results}
+}
+
+type DiffRevisionsRequest struct {
+	From int
+	To   int
+}
+
+func NewDiffRevisionsRequest(query url.Values) (DiffRevisionsRequest, error) {
+	if query.Get("from") == "" {
+		return DiffRevisionsRequest{}, fmt.Errorf("from is required")
+	}
+
+	from, err := parseIntParam(query, "from")
+	if err != nil {
+		return DiffRevisionsRequest{}, err
+	}
+
+	to, err := parseIntParam(query, "to")
+	if err != nil {
+		return DiffRevisionsRequest{}, err
+	}
+
+	return DiffRevisionsRequest{From: from, To: to}, nil
+}
+
+func parseIntParam(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return parsed, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected RFC 3339 time: %w", name, err)
+	}
+
+	parsed = parsed.UTC()
+
+	return &parsed, nil
+}
+
+type ShareNoteRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+	Role   string    `json:"role" validate:"required,oneof=viewer editor"`
+}
+
+func (snr ShareNoteRequest) ToDomain() usecase.ShareNoteInput {
+	return usecase.ShareNoteInput{
+		UserID: snr.UserID,
+		Role:   snr.Role,
+	}
+}
+
+type CreateLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password" validate:"omitempty,min=4,max=64"`
+}
+
+func (clr CreateLinkRequest) ToDomain() usecase.CreateLinkInput {
+	return usecase.CreateLinkInput{
+		ExpiresAt: clr.ExpiresAt,
+		Password:  clr.Password,
+	}
+}
+
+type CreateLinkResponse struct {
+	models.CreatedNoteLink
+	URL string `json:"url"`
+}
+
+func NewCreateLinkResponse(link *models.CreatedNoteLink) CreateLinkResponse {
+	return CreateLinkResponse{
+		CreatedNoteLink: *link,
+		URL:             "/public/notes/" + link.Token,
+	}
+}
+
+// MoveNoteRequest moves a note into a notebook. A null notebook_id takes the
+// note out of its notebook.
+type MoveNoteRequest struct {
+	NotebookID *uuid.UUID `json:"notebook_id"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type MergeTagsRequest struct {
+	Sources []string `json:"sources" validate:"required,min=1,dive,required,max=50"`
+	Target  string   `json:"target" validate:"required,max=50"`
+}
+
+func (mtr MergeTagsRequest) ToDomain() usecase.MergeTagsInput {
+	return usecase.MergeTagsInput{
+		Sources: mtr.Sources,
+		Target:  mtr.Target,
+	}
+}
+
+type TagsUpdatedResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+type SyncChangeRequest struct {
+	Op          string     `json:"op" validate:"required,oneof=create update delete"`
+	ID          uuid.UUID  `json:"id" validate:"required"`
+	Title       *string    `json:"title" validate:"omitempty,alphanum,min=1,max=50"`
+	Body        *string    `json:"body" validate:"omitempty,bytesize"`
+	Tags        *[]string  `json:"tags" validate:"omitempty"`
+	NotebookID  *uuid.UUID `json:"notebook_id"`
+	BaseVersion int64      `json:"base_version" validate:"min=0"`
+}
+
+type PushChangesRequest struct {
+	Changes []SyncChangeRequest `json:"changes" validate:"required,min=1,max=100,dive"`
+}
+
+func (pcr PushChangesRequest) ToDomain() []usecase.SyncChangeInput {
+	changes := make([]usecase.SyncChangeInput, 0, len(pcr.Changes))
+	for _, change := range pcr.Changes {
+		changes = append(changes, usecase.SyncChangeInput{
+			Op:          change.Op,
+			ID:          change.ID,
+			Title:       change.Title,
+			Body:        change.Body,
+			Tags:        change.Tags,
+			NotebookID:  change.NotebookID,
+			BaseVersion: change.BaseVersion,
+		})
+	}
+
+	return changes
+}
+
+type PushChangesResponse struct {
+	Results []models.SyncResult `json:"results"`
+}
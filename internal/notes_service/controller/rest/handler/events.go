@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+)
+
+const (
+	// heartbeatInterval keeps idle streams from being cut by proxies.
+	heartbeatInterval = 25 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// StreamEventsHandler
+// @Summary StreamEvents
+// @Description stream created, updated, deleted, shared and unshared events of the notes the user can see as Server-Sent Events
+// @Security JWTAuth
+// @Tags notes
+// @Produce text/event-stream
+// @Success 200
+// @Router /notes/events [get]
+func (c *NoteController) StreamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The stream stays open for as long as the client listens.
+	err := rc.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logrus.Error("error lifting write deadline", err)
+	}
+
+	events, unsubscribe := c.usecase.WatchNotes(ctx, currentUserID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err = rc.Flush(); err != nil {
+		logrus.Error("error flushing event stream", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			// A closed channel means the client fell behind; it reconnects
+			// and reads its notes again.
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				logrus.Error("error marshaling note event", err)
+				continue
+			}
+
+			if _, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// WatchEventsHandler
+// @Summary WatchEvents
+// @Description stream the same events as /notes/events over a WebSocket, one JSON message per event
+// @Security JWTAuth
+// @Tags notes
+// @Success 101
+// @Failure 400
+// @Router /notes/ws [get]
+func (c *NoteController) WatchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	// The upgrader answers a failed handshake itself.
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Error("error upgrading to websocket", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := c.usecase.WatchNotes(ctx, currentUserID)
+	defer unsubscribe()
+
+	// Clients send nothing but control frames; reading is still needed to
+	// answer pings and notice that the client went away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind")
+				_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteTimeout))
+				return
+			}
+
+			if err = writeEvent(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(conn *websocket.Conn, event models.NoteEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+
+	return conn.WriteJSON(event)
+}
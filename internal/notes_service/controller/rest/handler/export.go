@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportNotesHandler
+// @Summary ExportNotes
+// @Description download all notes as a zip of Markdown files with YAML front matter, in notebook folders and with their attachments
+// @Security JWTAuth
+// @Tags notes
+// @Produce application/zip
+// @Param format query string false "Archive format, only zip is supported"
+// @Success 200
+// @Failure 400
+// @Router /notes/export [get]
+func (c *NoteController) ExportNotesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "zip" {
+		http.Error(w, "format must be zip", http.StatusBadRequest)
+		return
+	}
+
+	// The archive may take longer to send than the server's write timeout
+	// allows for ordinary responses.
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logrus.Error("error lifting write deadline", err)
+	}
+
+	filename := fmt.Sprintf("notes-%s.zip", time.Now().UTC().Format("20060102"))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	// Once streaming has begun the status can't change anymore: a failure
+	// leaves the archive without its central directory, which any unzip
+	// tool reports as broken.
+	if err = c.usecase.ExportNotes(ctx, currentUserID, w); err != nil {
+		logrus.Error("error exporting notes", err)
+		return
+	}
+}
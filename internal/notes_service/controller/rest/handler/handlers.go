@@ -3,6 +3,8 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -20,26 +22,109 @@ const userIDKey = "userID"
 type NoteUsecase interface {
 	CreateNote(ctx context.Context, req usecase.CreateNoteInput) (uuid.UUID, error)
 	ReadNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.NoteOutput, error)
-	ReadAllNotes(ctx context.Context, currentUserID uuid.UUID) ([]models.NoteOutput, error)
-	UpdateNote(ctx context.Context, id uuid.UUID, req usecase.UpdateNoteInput) error
-	DeleteNote(ctx context.Context, id uuid.UUID) error
+	RenderNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.RenderedNote, error)
+	ReadAllNotes(ctx context.Context, currentUserID uuid.UUID, req usecase.ListNotesInput) (*models.NotesPage, error)
+	SearchNotes(ctx context.Context, currentUserID uuid.UUID, req usecase.SearchNotesInput) ([]models.NoteSearchResult, error)
+	UpdateNote(ctx context.Context, id, currentUserID uuid.UUID, req usecase.UpdateNoteInput) (int64, error)
+	DeleteNote(ctx context.Context, id, currentUserID uuid.UUID) error
+	ReadTrash(ctx context.Context, currentUserID uuid.UUID) ([]models.TrashedNote, error)
+	RestoreNote(ctx context.Context, id, currentUserID uuid.UUID) error
+	PurgeNote(ctx context.Context, id, currentUserID uuid.UUID) error
+	ShareNote(ctx context.Context, noteID, currentUserID uuid.UUID, req usecase.ShareNoteInput) error
+	ReadShares(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.NoteShare, error)
+	RevokeShare(ctx context.Context, noteID, userID, currentUserID uuid.UUID) error
+	ReadSharedNotes(ctx context.Context, currentUserID uuid.UUID) ([]models.SharedNote, error)
+	CreateLink(ctx context.Context, noteID, currentUserID uuid.UUID, req usecase.CreateLinkInput) (*models.CreatedNoteLink, error)
+	ReadLinks(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.NoteLink, error)
+	RevokeLink(ctx context.Context, noteID, linkID, currentUserID uuid.UUID) error
+	ReadPublicNote(ctx context.Context, token, password string) (*models.PublicNote, error)
+	MoveNote(ctx context.Context, noteID, currentUserID uuid.UUID, notebookID *uuid.UUID) error
+	ReadTags(ctx context.Context, currentUserID uuid.UUID) ([]models.TagCount, error)
+	RenameTag(ctx context.Context, currentUserID uuid.UUID, name, newName string) (int64, error)
+	MergeTags(ctx context.Context, currentUserID uuid.UUID, req usecase.MergeTagsInput) (int64, error)
+	ReadRevisions(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.NoteRevision, error)
+	ReadRevision(ctx context.Context, noteID uuid.UUID, number int, currentUserID uuid.UUID) (*models.NoteRevision, error)
+	DiffRevisions(ctx context.Context, noteID uuid.UUID, from, to int, currentUserID uuid.UUID) (*models.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, noteID uuid.UUID, number int, currentUserID uuid.UUID) error
+	AddAttachment(ctx context.Context, noteID, currentUserID uuid.UUID, req usecase.AddAttachmentInput) (*models.Attachment, error)
+	ReadAttachments(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.Attachment, error)
+	OpenAttachment(ctx context.Context, noteID, attachmentID, currentUserID uuid.UUID) (*models.Attachment, io.ReadSeekCloser, error)
+	DeleteAttachment(ctx context.Context, noteID, attachmentID, currentUserID uuid.UUID) error
+	ExportNotes(ctx context.Context, currentUserID uuid.UUID, w io.Writer) error
+	StartImport(ctx context.Context, currentUserID uuid.UUID, content io.Reader) (*models.ImportJob, error)
+	ReadImportJob(ctx context.Context, jobID, currentUserID uuid.UUID) (*models.ImportJob, error)
+	WatchNotes(ctx context.Context, currentUserID uuid.UUID) (<-chan models.NoteEvent, func())
+	PullChanges(ctx context.Context, currentUserID uuid.UUID, token string, limit int) (*models.SyncPage, error)
+	PushChanges(ctx context.Context, currentUserID uuid.UUID, changes []usecase.SyncChangeInput) ([]models.SyncResult, error)
 }
 
 type NoteController struct {
 	usecase      NoteUsecase
 	validator    *validator.Validate
 	tokenManager *token_manager.TokenManager
+	// limits cap the bodies of uploads.
+	limits usecase.UploadLimits
 }
 
 func (c *NoteController) Register(r chi.Router) {
+	scopes := middlewares.ReadWrite(middlewares.ScopeNotesRead, middlewares.ScopeNotesWrite)
+
 	r.Route("/notes", func(r chi.Router) {
-		r.Use(middlewares.UserIdentity(c.tokenManager))
+		r.Use(middlewares.UserIdentity(c.tokenManager, scopes))
 		r.Post("/", c.CreateNoteHandler)
+		r.Get("/search", c.SearchNotesHandler)
+		r.Get("/trash", c.GetTrashHandler)
+		r.Get("/shared", c.GetSharedNotesHandler)
+		r.Get("/import/{jobID}", c.GetImportJobHandler)
+		r.Delete("/trash/{id}", c.PurgeNoteHandler)
 		r.Get("/{id}", c.GetNoteHandler)
 		r.Get("/", c.GetAllNotesHandler)
 		r.Patch("/{id}", c.UpdateNoteHandler)
 		r.Delete("/{id}", c.DeleteNoteHandler)
+		r.Post("/{id}/restore", c.RestoreNoteHandler)
+		r.Post("/{id}/move", c.MoveNoteHandler)
+		r.Post("/{id}/shares", c.ShareNoteHandler)
+		r.Get("/{id}/shares", c.GetSharesHandler)
+		r.Delete("/{id}/shares/{userID}", c.RevokeShareHandler)
+		r.Post("/{id}/links", c.CreateLinkHandler)
+		r.Get("/{id}/links", c.GetLinksHandler)
+		r.Delete("/{id}/links/{linkID}", c.RevokeLinkHandler)
+		r.Get("/{id}/revisions", c.GetRevisionsHandler)
+		r.Get("/{id}/revisions/diff", c.DiffRevisionsHandler)
+		r.Get("/{id}/revisions/{revision}", c.GetRevisionHandler)
+		r.Post("/{id}/revisions/{revision}/restore", c.RestoreRevisionHandler)
+		r.Get("/{id}/attachments", c.GetAttachmentsHandler)
+		r.Delete("/{id}/attachments/{attachmentID}", c.DeleteAttachmentHandler)
+	})
+
+	r.Route("/tags", func(r chi.Router) {
+		r.Use(middlewares.UserIdentity(c.tokenManager, scopes))
+		r.Get("/", c.GetTagsHandler)
+		r.Post("/merge", c.MergeTagsHandler)
+		r.Patch("/{name}", c.RenameTagHandler)
 	})
+
+	r.Route("/sync", func(r chi.Router) {
+		r.Use(middlewares.UserIdentity(c.tokenManager, scopes))
+		r.Get("/", c.PullChangesHandler)
+		r.Post("/", c.PushChangesHandler)
+	})
+
+	r.Get("/public/notes/{token}", c.GetPublicNoteHandler)
+}
+
+// RegisterStreams registers the routes that stream large bodies in or out,
+// or stay open to push events, and may take longer than the request timeout
+// allows, so r must not enforce one.
+func (c *NoteController) RegisterStreams(r chi.Router) {
+	scopes := middlewares.ReadWrite(middlewares.ScopeNotesRead, middlewares.ScopeNotesWrite)
+
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Get("/notes/export", c.ExportNotesHandler)
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Post("/notes/import", c.ImportNotesHandler)
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Post("/notes/{id}/attachments", c.UploadAttachmentHandler)
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Get("/notes/{id}/attachments/{attachmentID}", c.DownloadAttachmentHandler)
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Get("/notes/events", c.StreamEventsHandler)
+	r.With(middlewares.UserIdentity(c.tokenManager, scopes)).Get("/notes/ws", c.WatchEventsHandler)
 }
 
 // CreateNoteHandler
@@ -52,6 +137,7 @@ func (c *NoteController) Register(r chi.Router) {
 // @Param note body controller.CreateNoteRequest true "Note info"
 // @Success 201
 // @Failure 400
+// @Failure 403
 // @Failure 500
 // @Router /notes [post]
 func (c *NoteController) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
@@ -82,6 +168,14 @@ func (c *NoteController) CreateNoteHandler(w http.ResponseWriter, r *http.Reques
 
 	noteID, err := c.usecase.CreateNote(ctx, domain)
 	if err != nil {
+		if errors.Is(err, models.ErrNotebookNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, models.ErrEmailNotVerified) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		logrus.Error("error creating note", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -105,8 +199,12 @@ func (c *NoteController) CreateNoteHandler(w http.ResponseWriter, r *http.Reques
 // @Accept json
 // @Produce json
 // @Param id path string true "Note ID"
+// @Param format query string false "json (default) or html to get the body rendered from Markdown"
 // @Success 200
+// @Header 200 {string} ETag "Note version"
 // @Failure 400
+// @Failure 403
+// @Failure 404
 // @Failure 500
 // @Router /notes/{id} [get]
 func (c *NoteController) GetNoteHandler(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +224,16 @@ func (c *NoteController) GetNoteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "html":
+		c.writeRenderedNote(w, r, parsedUUID, currentUserID)
+		return
+	default:
+		http.Error(w, "format must be json or html", http.StatusBadRequest)
+		return
+	}
+
 	note, err := c.usecase.ReadNote(ctx, parsedUUID, currentUserID)
 	if err != nil {
 		logrus.Error("error reading note", err)
@@ -133,8 +241,9 @@ func (c *NoteController) GetNoteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set(etagHeader, formatETag(note.Version))
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(note); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -143,11 +252,18 @@ func (c *NoteController) GetNoteHandler(w http.ResponseWriter, r *http.Request)
 
 // GetAllNotesHandler
 // @Summary GetAllNotes
-// @Description get all notes
+// @Description get a page of notes
 // @Security JWTAuth
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param sort query string false "created_at, updated_at or title"
+// @Param order query string false "asc or desc"
+// @Param tag query []string false "Only notes carrying all of these tags"
+// @Param created_from query string false "RFC 3339 lower bound for created_at"
+// @Param created_to query string false "RFC 3339 upper bound for created_at"
 // @Success 200
 // @Failure 400
 // @Failure 500
@@ -162,15 +278,87 @@ func (c *NoteController) GetAllNotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	notes, err := c.usecase.ReadAllNotes(ctx, currentUserID)
+	input, err := NewListNotesInput(r.URL.Query())
+	if err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := c.usecase.ReadAllNotes(ctx, currentUserID, input)
 	if err != nil {
+		if isListNotesInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logrus.Error("error reading notes", err)
 		http.Error(w, "failed to retrieve notes", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	if err = json.NewEncoder(w).Encode(notes); err != nil {
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SearchNotesHandler
+// @Summary SearchNotes
+// @Description full-text search over titles and bodies of the current user's notes
+// @Security JWTAuth
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query, supports quoted phrases, OR and -exclusions"
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param offset query int false "Number of results to skip"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /notes/search [get]
+func (c *NoteController) SearchNotesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	req, err := NewSearchNotesRequest(r.URL.Query())
+	if err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := c.usecase.SearchNotes(ctx, currentUserID, req.ToDomain())
+	if err != nil {
+		if errors.Is(err, usecase.ErrEmptySearchQuery) ||
+			errors.Is(err, usecase.ErrInvalidLimit) ||
+			errors.Is(err, usecase.ErrInvalidOffset) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logrus.Error("error searching notes", err)
+		http.Error(w, "failed to search notes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(NewSearchNotesResponse(results)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -178,15 +366,19 @@ func (c *NoteController) GetAllNotesHandler(w http.ResponseWriter, r *http.Reque
 
 // UpdateNoteHandler
 // @Summary UpdateNote
-// @Description update note
+// @Description update note, allowed to the author and editors. With the application/merge-patch+json
+// @Description content type only the fields present in the body are changed (RFC 7396).
 // @Security JWTAuth
 // @Tags notes
-// @Accept json
+// @Accept json,application/merge-patch+json
 // @Produce json
 // @Param id path string true "Note ID"
 // @Param note body controller.UpdateNoteRequest true "Note info"
+// @Param If-Match header string false "Version the update is based on, as returned in ETag"
 // @Success 200
+// @Header 200 {string} ETag "New note version"
 // @Failure 400
+// @Failure 412 {object} controller.VersionConflictResponse
 // @Failure 500
 // @Router /notes/{id} [patch]
 func (c *NoteController) UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
@@ -212,31 +404,66 @@ func (c *NoteController) UpdateNoteHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var req UpdateNoteRequest
+	var domain usecase.UpdateNoteInput
+
+	if isMergePatch(r) {
+		if domain, err = c.decodeMergePatch(r.Body); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var req UpdateNoteRequest
+
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err = c.validator.Struct(req); err != nil {
+			logrus.Error(err.(validator.ValidationErrors))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		domain = req.ToDomain()
+	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Error(err)
+	domain.ExpectedVersion, err = parseIfMatch(r)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err = c.validator.Struct(req); err != nil {
-		logrus.Error(err.(validator.ValidationErrors))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	version, err := c.usecase.UpdateNote(ctx, parsedUUID, currentUserID, domain)
+	if err != nil {
+		var conflict *models.VersionConflictError
+		if errors.As(err, &conflict) {
+			writeVersionConflict(w, conflict)
+			return
+		}
+
+		if errors.Is(err, models.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	domain := req.ToDomain()
-
-	err = c.usecase.UpdateNote(ctx, parsedUUID, domain)
+	note, err := c.usecase.ReadNote(ctx, parsedUUID, currentUserID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logrus.Error("error reading note", err)
+		http.Error(w, "error reading note", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set(etagHeader, formatETag(version))
 	w.Header().Set("Content-Type", "application/json")
-	if err = json.NewEncoder(w).Encode(req); err != nil {
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(note); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -244,7 +471,7 @@ func (c *NoteController) UpdateNoteHandler(w http.ResponseWriter, r *http.Reques
 
 // DeleteNoteHandler
 // @Summary DeleteNote
-// @Description delete note
+// @Description move note to the trash, only the author may do that
 // @Security JWTAuth
 // @Tags notes
 // @Accept json
@@ -277,8 +504,13 @@ func (c *NoteController) DeleteNoteHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = c.usecase.DeleteNote(ctx, parsedUUID)
+	err = c.usecase.DeleteNote(ctx, parsedUUID, currentUserID)
 	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -286,14 +518,23 @@ func (c *NoteController) DeleteNoteHandler(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func isListNotesInputError(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidCursor) ||
+		errors.Is(err, usecase.ErrInvalidSort) ||
+		errors.Is(err, usecase.ErrInvalidOrder) ||
+		errors.Is(err, usecase.ErrInvalidLimit)
+}
+
 func NewNoteController(
 	usecase NoteUsecase,
 	validator *validator.Validate,
 	tokenManager *token_manager.TokenManager,
+	limits usecase.UploadLimits,
 ) *NoteController {
 	return &NoteController{
 		usecase:      usecase,
 		validator:    validator,
 		tokenManager: tokenManager,
+		limits:       limits,
 	}
 }
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// uploadTimeout is how long an upload may take, in place of the server's
+// read and write timeouts, which are meant for ordinary requests.
+const uploadTimeout = 10 * time.Minute
+
+// @Summary ImportNotes
+// @Description queue the import of a zip of Markdown files or an Evernote .enex file, sent as the "file" field of a multipart form
+// @Security JWTAuth
+// @Tags import
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Zip of Markdown files or .enex file"
+// @Success 202
+// @Header 202 {string} Location "Status of the import job"
+// @Failure 400
+// @Failure 403
+// @Failure 413
+// @Failure 415
+// @Router /notes/import [post]
+func (c *NoteController) ImportNotesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	extendDeadlines(w, uploadTimeout)
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.limits.MaxImportSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	part, err := nextFilePart(reader)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+	defer part.Close()
+
+	job, err := c.usecase.StartImport(ctx, currentUserID, part)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/notes/import/"+job.ID.String())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetImportJobHandler
+// @Summary GetImportJob
+// @Description get the status of an import job with the counts of created, skipped and failed notes
+// @Security JWTAuth
+// @Tags import
+// @Produce json
+// @Param jobID path string true "Import job ID"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /notes/import/{jobID} [get]
+func (c *NoteController) GetImportJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid import job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := c.usecase.ReadImportJob(ctx, jobID, currentUserID)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, errNoAttachmentFile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrImportTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, usecase.ErrImportTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, usecase.ErrUnsupportedImportFormat):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, models.ErrImportJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrEmailNotVerified):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		logrus.Error("error importing notes", err)
+		http.Error(w, "error importing notes", http.StatusInternalServerError)
+	}
+}
+
+// extendDeadlines gives the request until timeout from now to be read and
+// answered. The response deadline moves too, since the server starts
+// counting it before the body is read.
+func extendDeadlines(w http.ResponseWriter, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	rc := http.NewResponseController(w)
+
+	err := rc.SetReadDeadline(deadline)
+	if err == nil {
+		err = rc.SetWriteDeadline(deadline)
+	}
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logrus.Error("error extending deadlines", err)
+	}
+}
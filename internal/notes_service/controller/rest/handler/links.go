@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+const linkPasswordHeader = "X-Link-Password"
+
+var publicNoteTemplate = template.Must(template.New("note").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+{{if .Tags}}<p>{{range .Tags}}<span class="tag">#{{.}}</span> {{end}}</p>{{end}}
+<pre>{{.Body}}</pre>
+<footer>Last updated {{.UpdatedAt.Format "2006-01-02 15:04"}} UTC</footer>
+</article>
+</body>
+</html>
+`))
+
+// CreateLinkHandler
+// @Summary CreateLink
+// @Description create a public read-only link to a note, the token is only returned once
+// @Security JWTAuth
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param link body handler.CreateLinkRequest true "Link options"
+// @Success 201
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/links [post]
+func (c *NoteController) CreateLinkHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateLinkRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := c.usecase.CreateLink(ctx, noteID, currentUserID, req.ToDomain())
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(NewCreateLinkResponse(link)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetLinksHandler
+// @Summary GetLinks
+// @Description list the public links of a note
+// @Security JWTAuth
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/links [get]
+func (c *NoteController) GetLinksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	links, err := c.usecase.ReadLinks(ctx, noteID, currentUserID)
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(links); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokeLinkHandler
+// @Summary RevokeLink
+// @Description revoke a public link of a note
+// @Security JWTAuth
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param linkID path string true "Link ID"
+// @Success 204
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/links/{linkID} [delete]
+func (c *NoteController) RevokeLinkHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	linkID, err := uuid.Parse(chi.URLParam(r, "linkID"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid link id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RevokeLink(ctx, noteID, linkID, currentUserID); err != nil {
+		writeLinkError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPublicNoteHandler
+// @Summary GetPublicNote
+// @Description read a note through a public link, as JSON or as an HTML page with ?format=html
+// @Tags links
+// @Produce json
+// @Produce html
+// @Param token path string true "Link token"
+// @Param format query string false "json or html"
+// @Param X-Link-Password header string false "Link password"
+// @Success 200
+// @Failure 401
+// @Failure 404
+// @Failure 410
+// @Router /public/notes/{token} [get]
+func (c *NoteController) GetPublicNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	note, err := c.usecase.ReadPublicNote(ctx, chi.URLParam(r, "token"), r.Header.Get(linkPasswordHeader))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidLinkPassword):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, usecase.ErrLinkExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+		default:
+			logrus.Error("error reading public note", err)
+			http.Error(w, "link is not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	if wantsHTML(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err = publicNoteTemplate.Execute(w, note); err != nil {
+			logrus.Error("error rendering public note", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(note); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// wantsHTML picks the representation of a public note: an explicit format
+// query parameter wins over the Accept header.
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func writeLinkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidExpiry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, models.ErrLinkNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logrus.Error("error managing links", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+	}
+}
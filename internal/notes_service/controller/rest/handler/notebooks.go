@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+)
+
+// MoveNoteHandler
+// @Summary MoveNote
+// @Description move a note into a notebook, a null notebook_id takes it out of its notebook
+// @Security JWTAuth
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param notebook body handler.MoveNoteRequest true "Target notebook"
+// @Success 204
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/move [post]
+func (c *NoteController) MoveNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	var req MoveNoteRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.MoveNote(ctx, noteID, currentUserID, req.NotebookID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, models.ErrNotebookNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			logrus.Error("error moving note", err)
+			http.Error(w, "id is not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
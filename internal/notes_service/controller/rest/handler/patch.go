@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// mergePatchContentType selects JSON Merge Patch (RFC 7396) semantics for
+// PATCH /notes/{id}: only the members present in the body are changed.
+const mergePatchContentType = "application/merge-patch+json"
+
+var errMergePatchNotObject = errors.New("merge patch must be a JSON object")
+
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == mergePatchContentType
+}
+
+// decodeMergePatch turns a merge patch into an update of the fields it
+// names. A null member removes the field: tags become empty, while title and
+// body, which every note must have, can't be removed.
+func (c *NoteController) decodeMergePatch(body io.Reader) (usecase.UpdateNoteInput, error) {
+	var input usecase.UpdateNoteInput
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		return input, err
+	}
+
+	if patch == nil {
+		return input, errMergePatchNotObject
+	}
+
+	for field, raw := range patch {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		switch field {
+		case "title":
+			if isNull {
+				return input, fmt.Errorf("title can't be removed")
+			}
+
+			var title string
+			if err := json.Unmarshal(raw, &title); err != nil {
+				return input, fmt.Errorf("title: %w", err)
+			}
+			if err := c.validator.Var(title, "required,alphanum,min=1,max=50"); err != nil {
+				return input, fmt.Errorf("title: %w", err)
+			}
+			input.Title = &title
+		case "body":
+			if isNull {
+				return input, fmt.Errorf("body can't be removed")
+			}
+
+			var text string
+			if err := json.Unmarshal(raw, &text); err != nil {
+				return input, fmt.Errorf("body: %w", err)
+			}
+			if err := c.validator.Var(text, "required,bytesize"); err != nil {
+				return input, fmt.Errorf("body: %w", err)
+			}
+			input.Body = &text
+		case "tags":
+			tags := []string{}
+			if !isNull {
+				if err := json.Unmarshal(raw, &tags); err != nil {
+					return input, fmt.Errorf("tags: %w", err)
+				}
+			}
+			input.Tags = &tags
+		default:
+			return input, fmt.Errorf("field %q can't be patched", field)
+		}
+	}
+
+	return input, nil
+}
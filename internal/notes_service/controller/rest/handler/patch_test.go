@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"notes-rew/internal/notes_service/usecase"
+	"notes-rew/internal/validators"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func tagsPtr(tags ...string) *[]string {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &tags
+}
+
+// TestDecodeMergePatch follows the examples of RFC 7396, appendix A, as far
+// as they apply to a note: a flat object whose title and body can't be
+// removed and whose only array is tags.
+func TestDecodeMergePatch(t *testing.T) {
+	validate := validator.New()
+	validators.RegisterCustomValidation(validate)
+
+	c := &NoteController{validator: validate}
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    usecase.UpdateNoteInput
+		wantErr bool
+	}{
+		{
+			name:  "member is replaced",
+			patch: `{"title":"c"}`,
+			want:  usecase.UpdateNoteInput{Title: stringPtr("c")},
+		},
+		{
+			name:  "member is added",
+			patch: `{"body":"b"}`,
+			want:  usecase.UpdateNoteInput{Body: stringPtr("b")},
+		},
+		{
+			name:  "null removes the member",
+			patch: `{"tags":null}`,
+			want:  usecase.UpdateNoteInput{Tags: tagsPtr()},
+		},
+		{
+			name:  "replace and remove together",
+			patch: `{"title":"b","tags":null}`,
+			want:  usecase.UpdateNoteInput{Title: stringPtr("b"), Tags: tagsPtr()},
+		},
+		{
+			name:  "array is replaced as a whole",
+			patch: `{"tags":["c"]}`,
+			want:  usecase.UpdateNoteInput{Tags: tagsPtr("c")},
+		},
+		{
+			name:  "empty array clears the array",
+			patch: `{"tags":[]}`,
+			want:  usecase.UpdateNoteInput{Tags: tagsPtr()},
+		},
+		{
+			name:  "empty patch changes nothing",
+			patch: `{}`,
+			want:  usecase.UpdateNoteInput{},
+		},
+		{
+			name:  "JSON in a string stays text",
+			patch: `{"body":"{\"a\":null}"}`,
+			want:  usecase.UpdateNoteInput{Body: stringPtr(`{"a":null}`)},
+		},
+		{
+			name:    "required member can't be removed",
+			patch:   `{"title":null}`,
+			wantErr: true,
+		},
+		{
+			name:    "body can't be removed",
+			patch:   `{"body":null}`,
+			wantErr: true,
+		},
+		{
+			name:    "nested object where an array is expected",
+			patch:   `{"tags":{"a":"b"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "nested object where a string is expected",
+			patch:   `{"title":{"a":{"b":"c"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown member",
+			patch:   `{"a":{"bb":{"ccc":null}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "array patch would replace the note",
+			patch:   `["c"]`,
+			wantErr: true,
+		},
+		{
+			name:    "null patch would remove the note",
+			patch:   `null`,
+			wantErr: true,
+		},
+		{
+			name:    "scalar patch would replace the note",
+			patch:   `"c"`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid title",
+			patch:   `{"title":"not alphanumeric!"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.decodeMergePatch(strings.NewReader(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeMergePatch(%s) accepted the patch", tt.patch)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeMergePatch(%s): %v", tt.patch, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeMergePatch(%s) = %+v, want %+v", tt.patch, got, tt.want)
+			}
+		})
+	}
+}
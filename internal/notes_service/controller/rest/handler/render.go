@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+)
+
+// writeRenderedNote answers GET /notes/{id}?format=html with the note body
+// rendered to sanitized HTML and its table of contents.
+func (c *NoteController) writeRenderedNote(w http.ResponseWriter, r *http.Request, noteID, currentUserID uuid.UUID) {
+	rendered, err := c.usecase.RenderNote(r.Context(), noteID, currentUserID)
+	if err != nil {
+		writeRenderError(w, err)
+		return
+	}
+
+	w.Header().Set(etagHeader, formatETag(rendered.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(rendered); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeRenderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrNoteNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		logrus.Error("error rendering note", err)
+		http.Error(w, "error rendering note", http.StatusInternalServerError)
+	}
+}
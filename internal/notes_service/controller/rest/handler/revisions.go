@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// GetRevisionsHandler
+// @Summary GetRevisions
+// @Description list the archived revisions of a note, newest first
+// @Security JWTAuth
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /notes/{id}/revisions [get]
+func (c *NoteController) GetRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := c.usecase.ReadRevisions(ctx, noteID, currentUserID)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(revisions); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRevisionHandler
+// @Summary GetRevision
+// @Description get a single revision of a note
+// @Security JWTAuth
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param revision path int true "Revision number"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /notes/{id}/revisions/{revision} [get]
+func (c *NoteController) GetRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil {
+		http.Error(w, "invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := c.usecase.ReadRevision(ctx, noteID, number, currentUserID)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(revision); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DiffRevisionsHandler
+// @Summary DiffRevisions
+// @Description line diff of the body between two revisions of a note
+// @Security JWTAuth
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param from query int true "Revision to diff from, 0 for the current version"
+// @Param to query int false "Revision to diff to, the current version by default"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /notes/{id}/revisions/diff [get]
+func (c *NoteController) DiffRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	req, err := NewDiffRevisionsRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.usecase.DiffRevisions(ctx, noteID, req.From, req.To, currentUserID)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreRevisionHandler
+// @Summary RestoreRevision
+// @Description make an old revision the current version of the note
+// @Security JWTAuth
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param revision path int true "Revision number"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /notes/{id}/revisions/{revision}/restore [post]
+func (c *NoteController) RestoreRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil {
+		http.Error(w, "invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RestoreRevision(ctx, noteID, number, currentUserID); err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRevision):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrRevisionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logrus.Error("error reading revisions", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+	}
+}
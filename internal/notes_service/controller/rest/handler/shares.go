@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// ShareNoteHandler
+// @Summary ShareNote
+// @Description grant another user the viewer or editor role on a note
+// @Security JWTAuth
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param share body handler.ShareNoteRequest true "Share info"
+// @Success 204
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /notes/{id}/shares [post]
+func (c *NoteController) ShareNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	var req ShareNoteRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.ShareNote(ctx, noteID, currentUserID, req.ToDomain()); err != nil {
+		writeShareError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharesHandler
+// @Summary GetShares
+// @Description list the users a note is shared with
+// @Security JWTAuth
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/shares [get]
+func (c *NoteController) GetSharesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	shares, err := c.usecase.ReadShares(ctx, noteID, currentUserID)
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(shares); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokeShareHandler
+// @Summary RevokeShare
+// @Description revoke a user's access to a note, users may also leave a note shared with them
+// @Security JWTAuth
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Param userID path string true "User ID"
+// @Success 204
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Router /notes/{id}/shares/{userID} [delete]
+func (c *NoteController) RevokeShareHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RevokeShare(ctx, noteID, userID, currentUserID); err != nil {
+		writeShareError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharedNotesHandler
+// @Summary GetSharedNotes
+// @Description list the notes other users shared with the current user
+// @Security JWTAuth
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Success 200
+// @Failure 500
+// @Router /notes/shared [get]
+func (c *NoteController) GetSharedNotesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notes, err := c.usecase.ReadSharedNotes(ctx, currentUserID)
+	if err != nil {
+		logrus.Error("error reading shared notes", err)
+		http.Error(w, "failed to retrieve shared notes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(notes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeShareError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRole), errors.Is(err, usecase.ErrShareToOwner):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrShareNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logrus.Error("error managing shares", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+	}
+}
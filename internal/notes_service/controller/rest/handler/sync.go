@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// PullChangesHandler
+// @Summary PullChanges
+// @Description get the notes of the current user changed since a sync token, tombstones for the deleted ones and the next sync token
+// @Security JWTAuth
+// @Tags sync
+// @Produce json
+// @Param since query string false "Sync token from the previous response, omitted on the first sync"
+// @Param limit query int false "Maximum number of changes, 200 by default and at most 1000"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /sync [get]
+func (c *NoteController) PullChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			http.Error(w, usecase.ErrInvalidSyncLimit.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := c.usecase.PullChanges(ctx, currentUserID, r.URL.Query().Get("since"), limit)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSyncToken) || errors.Is(err, usecase.ErrInvalidSyncLimit) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logrus.Error("error pulling changes", err)
+		http.Error(w, "failed to retrieve changes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// PushChangesHandler
+// @Summary PushChanges
+// @Description apply a batch of changes made offline, each with its own result: applied, conflict, not_found, rejected or failed
+// @Security JWTAuth
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param changes body handler.PushChangesRequest true "Changes in the order they were made"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /sync [post]
+func (c *NoteController) PushChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	var req PushChangesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := c.usecase.PushChanges(ctx, currentUserID, req.ToDomain())
+	if err != nil {
+		if errors.Is(err, usecase.ErrNoChanges) || errors.Is(err, usecase.ErrTooManyChanges) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logrus.Error("error pushing changes", err)
+		http.Error(w, "failed to apply changes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(PushChangesResponse{Results: results}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/usecase"
+)
+
+// GetTagsHandler
+// @Summary GetTags
+// @Description list the tags of the current user with the number of notes carrying each
+// @Security JWTAuth
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 200
+// @Failure 500
+// @Router /tags [get]
+func (c *NoteController) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	tags, err := c.usecase.ReadTags(ctx, currentUserID)
+	if err != nil {
+		logrus.Error("error reading tags", err)
+		http.Error(w, "failed to retrieve tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RenameTagHandler
+// @Summary RenameTag
+// @Description rename a tag across all notes of the current user
+// @Security JWTAuth
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param name path string true "Tag name"
+// @Param tag body handler.RenameTagRequest true "New tag name"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /tags/{name} [patch]
+func (c *NoteController) RenameTagHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	if err != nil {
+		http.Error(w, "invalid tag name", http.StatusBadRequest)
+		return
+	}
+
+	var req RenameTagRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := c.usecase.RenameTag(ctx, currentUserID, name, req.Name)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(TagsUpdatedResponse{Updated: updated}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// MergeTagsHandler
+// @Summary MergeTags
+// @Description replace several tags with a single one across all notes of the current user
+// @Security JWTAuth
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tags body handler.MergeTagsRequest true "Tags to merge"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /tags/merge [post]
+func (c *NoteController) MergeTagsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	var req MergeTagsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.validator.Struct(req); err != nil {
+		logrus.Error(err.(validator.ValidationErrors))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := c.usecase.MergeTags(ctx, currentUserID, req.ToDomain())
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(TagsUpdatedResponse{Updated: updated}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrEmptyTag),
+		errors.Is(err, usecase.ErrNoSourceTags),
+		errors.Is(err, usecase.ErrSameTagRenamed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logrus.Error("error updating tags", err)
+		http.Error(w, "failed to update tags", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// GetTrashHandler
+// @Summary GetTrash
+// @Description list the notes in the trash, most recently deleted first
+// @Security JWTAuth
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Success 200
+// @Failure 500
+// @Router /notes/trash [get]
+func (c *NoteController) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	notes, err := c.usecase.ReadTrash(ctx, currentUserID)
+	if err != nil {
+		logrus.Error("error reading trash", err)
+		http.Error(w, "failed to retrieve trash", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(notes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreNoteHandler
+// @Summary RestoreNote
+// @Description move a note out of the trash
+// @Security JWTAuth
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Router /notes/{id}/restore [post]
+func (c *NoteController) RestoreNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.RestoreNote(ctx, noteID, currentUserID); err != nil {
+		logrus.Error("error restoring note", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeNoteHandler
+// @Summary PurgeNote
+// @Description permanently delete a note from the trash
+// @Security JWTAuth
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path string true "Note ID"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Router /notes/trash/{id} [delete]
+func (c *NoteController) PurgeNoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUserID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	if !ok {
+		logrus.Error("error reading id from context")
+		http.Error(w, "error reading id", http.StatusNotFound)
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		logrus.Error("error converting string to UUID", err)
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return
+	}
+
+	if err = c.usecase.PurgeNote(ctx, noteID, currentUserID); err != nil {
+		logrus.Error("error purging note", err)
+		http.Error(w, "id is not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
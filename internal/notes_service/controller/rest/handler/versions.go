@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notes-rew/internal/notes_service/models"
+)
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+var errInvalidIfMatch = errors.New(`If-Match must be a single note version such as "3"`)
+
+// formatETag turns a note version into a strong entity tag.
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch reads the version a client based its update on. A missing
+// header or "*" puts no condition on the update. Weak tags never match under
+// the strong comparison If-Match requires, so they are rejected.
+func parseIfMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		return nil, errInvalidIfMatch
+	}
+
+	return &version, nil
+}
+
+// writeVersionConflict answers an update against an outdated version with
+// 412 and the version the client has to fetch.
+func writeVersionConflict(w http.ResponseWriter, conflict *models.VersionConflictError) {
+	w.Header().Set(etagHeader, formatETag(conflict.CurrentVersion))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	if err := json.NewEncoder(w).Encode(NewVersionConflictResponse(conflict)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+)
+
+const (
+	channel = "notes:v2:events"
+
+	// subscriptionBuffer is how many events a subscriber may fall behind
+	// before it is dropped.
+	subscriptionBuffer = 64
+)
+
+// envelope is an event as it travels through Redis, recipients included.
+type envelope struct {
+	Event      models.NoteEvent `json:"event"`
+	Recipients []uuid.UUID      `json:"recipients"`
+}
+
+// Bus fans note events out to the subscribers of every instance through
+// Redis pub/sub. Each instance keeps one Redis subscription and hands the
+// events to its local subscribers, by recipient.
+//
+// Without Redis, or when publishing to it fails, events only reach the
+// subscribers of the instance that published them.
+type Bus struct {
+	client      *redis.Client
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[*subscription]struct{}
+}
+
+type subscription struct {
+	userID uuid.UUID
+	events chan models.NoteEvent
+}
+
+// Publish sends the event to its recipients on every instance.
+func (b *Bus) Publish(ctx context.Context, event models.NoteEvent) {
+	if b.client == nil {
+		b.dispatch(event)
+		return
+	}
+
+	data, err := json.Marshal(envelope{Event: event, Recipients: event.Recipients})
+	if err != nil {
+		logrus.Printf("error while marshaling note event: %v", err)
+		return
+	}
+
+	if err = b.client.Publish(ctx, channel, data).Err(); err != nil {
+		logrus.Printf("error while publishing note event, delivering it locally: %v", err)
+		b.dispatch(event)
+	}
+}
+
+// Subscribe returns the events userID may see and a function that ends the
+// subscription. The channel is closed when the subscription ends, including
+// when the subscriber falls too far behind: it should then reconnect and
+// read the notes again.
+func (b *Bus) Subscribe(userID uuid.UUID) (<-chan models.NoteEvent, func()) {
+	sub := &subscription{
+		userID: userID,
+		events: make(chan models.NoteEvent, subscriptionBuffer),
+	}
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+
+	return sub.events, func() {
+		once.Do(func() { b.unsubscribe(sub) })
+	}
+}
+
+func (b *Bus) unsubscribe(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subscribers[sub.userID]
+	if !ok {
+		return
+	}
+
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+
+	close(sub.events)
+}
+
+// Run relays the events published through Redis to the local subscribers.
+// It blocks until ctx is cancelled; go-redis restores the subscription
+// after connection failures on its own.
+func (b *Bus) Run(ctx context.Context) {
+	if b.client == nil {
+		<-ctx.Done()
+		return
+	}
+
+	pubsub := b.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var env envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				logrus.Printf("error while unmarshaling note event: %v", err)
+				continue
+			}
+
+			env.Event.Recipients = env.Recipients
+			b.dispatch(env.Event)
+		}
+	}
+}
+
+func (b *Bus) dispatch(event models.NoteEvent) {
+	var lagging []*subscription
+
+	b.mu.RLock()
+	for _, userID := range event.Recipients {
+		for sub := range b.subscribers[userID] {
+			select {
+			case sub.events <- event:
+			default:
+				lagging = append(lagging, sub)
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range lagging {
+		b.unsubscribe(sub)
+	}
+}
+
+func NewBus(client *redis.Client) *Bus {
+	return &Bus{
+		client:      client,
+		subscribers: make(map[uuid.UUID]map[*subscription]struct{}),
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoteNotFound       = errors.New("note not found")
+	ErrNoteExists         = errors.New("note already exists")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrShareNotFound      = errors.New("share not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrLinkNotFound       = errors.New("link not found")
+	ErrNotebookNotFound   = errors.New("notebook not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrImportJobNotFound  = errors.New("import job not found")
+	ErrForbidden          = errors.New("not enough permissions for this note")
+	ErrVersionMismatch    = errors.New("note version mismatch")
+	ErrEmailNotVerified   = errors.New("email must be verified to create notes")
+)
+
+// VersionConflictError rejects an update made against an outdated version
+// of a note. It matches ErrVersionMismatch.
+type VersionConflictError struct {
+	CurrentVersion int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: current version is %d", ErrVersionMismatch, e.CurrentVersion)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
@@ -4,14 +4,237 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"notes-rew/internal/diff"
 )
 
 type NoteOutput struct {
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	Tags       []string   `json:"tags"`
+	Author     uuid.UUID  `json:"author"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	NotebookID *uuid.UUID `json:"notebook_id,omitempty"`
+	Version    int64      `json:"version"`
+}
+
+type NotesPage struct {
+	Notes      []NoteOutput `json:"notes"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type NoteSearchResult struct {
+	NoteOutput
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// NoteRevision is a snapshot of a note taken right before it was overwritten.
+type NoteRevision struct {
 	ID        uuid.UUID `json:"id"`
+	NoteID    uuid.UUID `json:"note_id"`
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionDiff compares two revisions of a note. A zero revision number
+// stands for the current version of the note.
+type RevisionDiff struct {
+	NoteID    uuid.UUID   `json:"note_id"`
+	From      int         `json:"from"`
+	To        int         `json:"to"`
+	TitleFrom string      `json:"title_from"`
+	TitleTo   string      `json:"title_to"`
+	TagsFrom  []string    `json:"tags_from"`
+	TagsTo    []string    `json:"tags_to"`
+	Lines     []diff.Line `json:"lines"`
+}
+
+type TrashedNote struct {
+	NoteOutput
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+)
+
+type NoteShare struct {
+	NoteID    uuid.UUID `json:"note_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SharedNote struct {
+	NoteOutput
+	Role string `json:"role"`
+}
+
+// NoteLink is a public read-only link to a note. Only a hash of its token is
+// stored, so the token itself is shown once, when the link is created.
+type NoteLink struct {
+	ID           uuid.UUID  `json:"id"`
+	NoteID       uuid.UUID  `json:"note_id"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	PasswordHash string     `json:"-"`
+}
+
+type CreatedNoteLink struct {
+	NoteLink
+	Token string `json:"token"`
+}
+
+// PublicNote is what anonymous visitors of a link get to see.
+type PublicNote struct {
 	Title     string    `json:"title"`
 	Body      string    `json:"body"`
 	Tags      []string  `json:"tags"`
-	Author    uuid.UUID `json:"author"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// Attachment is a file attached to a note. Its content lives in the blob
+// store under StorageKey.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	NoteID      uuid.UUID `json:"note_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	StorageKey  string    `json:"-"`
+}
+
+// RenderedNote is a note with its body rendered from Markdown to sanitized
+// HTML.
+type RenderedNote struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Version int64     `json:"version"`
+	HTML    string    `json:"html"`
+	TOC     []Heading `json:"toc"`
+}
+
+// Heading is an entry of the table of contents of a rendered note. ID is the
+// anchor of the heading in the HTML.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// ExportedNote is a note as it goes into an export: Folder holds the names of
+// its notebook and the notebook's ancestors, outermost first, and is empty
+// for notes outside of notebooks.
+type ExportedNote struct {
+	NoteOutput
+	Folder      []string
+	Attachments []Attachment
+}
+
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportJob tracks the import of an uploaded file. Error is set when the job
+// failed as a whole, Failures list the items that couldn't be imported.
+type ImportJob struct {
+	ID         uuid.UUID       `json:"id"`
+	Format     string          `json:"format"`
+	Status     string          `json:"status"`
+	Created    int             `json:"created"`
+	Skipped    int             `json:"skipped"`
+	Failed     int             `json:"failed"`
+	Failures   []ImportFailure `json:"failures"`
+	Error      *string         `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	OwnerID    uuid.UUID       `json:"-"`
+	SourceKey  string          `json:"-"`
+}
+
+type ImportFailure struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+const (
+	NoteCreated  = "note.created"
+	NoteUpdated  = "note.updated"
+	NoteDeleted  = "note.deleted"
+	NoteShared   = "note.shared"
+	NoteUnshared = "note.unshared"
+)
+
+// NoteEvent tells that a note changed. It carries no content: clients read
+// the note again when they need it. Recipients are the users who may see
+// the event.
+type NoteEvent struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       string      `json:"type"`
+	NoteID     uuid.UUID   `json:"note_id"`
+	Version    int64       `json:"version,omitempty"`
+	ActorID    uuid.UUID   `json:"actor_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Recipients []uuid.UUID `json:"-"`
+}
+
+// NoteChange is a note as it stands after a change, with DeletedAt set when
+// the change moved it to the trash or purged it. A purged note only keeps
+// its ID and author.
+type NoteChange struct {
+	Note      NoteOutput
+	DeletedAt *time.Time
+	Seq       int64
+}
+
+// SyncPage is the part of the changes to the user's notes since a sync
+// token that fits in one response. Pass SyncToken back to continue.
+type SyncPage struct {
+	Notes      []NoteOutput `json:"notes"`
+	Tombstones []Tombstone  `json:"tombstones"`
+	SyncToken  string       `json:"sync_token"`
+	HasMore    bool         `json:"has_more"`
+}
+
+// Tombstone stands for a note that was moved to the trash or purged.
+type Tombstone struct {
+	ID        uuid.UUID `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncNotFound = "not_found"
+	SyncRejected = "rejected"
+	SyncFailed   = "failed"
+)
+
+// SyncResult tells what became of one change pushed by a client. Version
+// is the version of the note after an applied change; CurrentVersion is
+// the one the client has to merge with after a conflict.
+type SyncResult struct {
+	ID             uuid.UUID `json:"id"`
+	Status         string    `json:"status"`
+	Version        int64     `json:"version,omitempty"`
+	CurrentVersion int64     `json:"current_version,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
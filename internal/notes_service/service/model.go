@@ -4,16 +4,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
 )
 
 type CreateNote struct {
-	ID        uuid.UUID
-	Title     string
-	Body      string
-	Tags      []string
-	Author    uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         uuid.UUID
+	Title      string
+	Body       string
+	Tags       []string
+	Author     uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	NotebookID *uuid.UUID
 }
 
 func NewCreateNote(
@@ -37,8 +39,89 @@ func NewCreateNote(
 }
 
 type UpdateNote struct {
-	Title     *string
-	Body      *string
-	Tags      *[]string
-	UpdatedAt time.Time
+	Title           *string
+	Body            *string
+	Tags            *[]string
+	UpdatedAt       time.Time
+	ExpectedVersion *int64
+}
+
+type NotesQuery struct {
+	AuthorID    uuid.UUID
+	Limit       uint64
+	SortBy      string
+	Desc        bool
+	Tags        []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	After       *NotesCursor
+}
+
+// NotesCursor is the keyset position of the last note on the previous page.
+type NotesCursor struct {
+	Value interface{}
+	ID    uuid.UUID
+}
+
+type SearchQuery struct {
+	AuthorID uuid.UUID
+	Query    string
+	Limit    uint64
+	Offset   uint64
+}
+
+type CreateShare struct {
+	NoteID    uuid.UUID
+	UserID    uuid.UUID
+	Role      string
+	CreatedAt time.Time
+}
+
+type CreateLink struct {
+	ID           uuid.UUID
+	NoteID       uuid.UUID
+	TokenHash    string
+	PasswordHash *string
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
+}
+
+type CreateAttachment struct {
+	ID          uuid.UUID
+	NoteID      uuid.UUID
+	OwnerID     uuid.UUID
+	Filename    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+	CreatedAt   time.Time
+}
+
+type CreateImportJob struct {
+	ID        uuid.UUID
+	OwnerID   uuid.UUID
+	Format    string
+	SourceKey string
+	CreatedAt time.Time
+}
+
+// UpdateImportJob records the progress of a job, and its outcome once
+// FinishedAt is set.
+type UpdateImportJob struct {
+	ID         uuid.UUID
+	Status     string
+	Created    int
+	Skipped    int
+	Failed     int
+	Failures   []models.ImportFailure
+	Error      *string
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+type CreateImportedNote struct {
+	OwnerID uuid.UUID
+	ItemKey string
+	NoteID  uuid.UUID
+	JobID   uuid.UUID
 }
@@ -2,86 +2,386 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
-	"github.com/sirupsen/logrus"
+	"notes-rew/internal/db/transactor"
 	"notes-rew/internal/notes_service/models"
 	"time"
 )
 
-const ExpirationTime = time.Hour * 24
-
 type NoteStorage interface {
-	CreateNoteByID(ctx context.Context, note CreateNote) error
+	CreateNoteByID(ctx context.Context, note CreateNote) (int64, error)
 	GetNoteByID(ctx context.Context, id uuid.UUID) (models.NoteOutput, error)
 	GetAllNotesByAuthorID(ctx context.Context, currentUserID uuid.UUID) ([]models.NoteOutput, error)
-	UpdateNoteByID(ctx context.Context, id uuid.UUID, note UpdateNote) error
-	DeleteNoteByID(ctx context.Context, id uuid.UUID) error
+	GetNotesByQuery(ctx context.Context, query NotesQuery) ([]models.NoteOutput, error)
+	SearchNotes(ctx context.Context, query SearchQuery) ([]models.NoteSearchResult, error)
+	UpdateNoteByID(ctx context.Context, id uuid.UUID, note UpdateNote) (int64, error)
+	DeleteNoteByID(ctx context.Context, id uuid.UUID, deletedAt time.Time, expectedVersion *int64) error
+	MoveNoteByID(ctx context.Context, id uuid.UUID, notebookID *uuid.UUID, updatedAt time.Time) error
+	GetNotebookAuthorID(ctx context.Context, notebookID uuid.UUID) (uuid.UUID, error)
+	GetTagCounts(ctx context.Context, authorID uuid.UUID) ([]models.TagCount, error)
+	ReplaceTags(ctx context.Context, authorID uuid.UUID, sources []string, target string, updatedAt time.Time) ([]uuid.UUID, error)
+	GetTrashedNoteByID(ctx context.Context, id uuid.UUID) (models.TrashedNote, error)
+	GetTrashedNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.TrashedNote, error)
+	RestoreNoteByID(ctx context.Context, id uuid.UUID) error
+	PurgeNoteByID(ctx context.Context, id uuid.UUID) error
+	PurgeTrash(ctx context.Context, trashedBefore time.Time) (int64, error)
+	CreateRevision(ctx context.Context, noteID, revisionID uuid.UUID, createdAt time.Time) error
+	GetRevisionsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID uuid.UUID, number int) (models.NoteRevision, error)
+	SaveShare(ctx context.Context, share CreateShare) error
+	GetShareRole(ctx context.Context, noteID, userID uuid.UUID) (string, error)
+	GetSharesByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteShare, error)
+	DeleteShare(ctx context.Context, noteID, userID uuid.UUID) error
+	GetNotesSharedWithUser(ctx context.Context, userID uuid.UUID) ([]models.SharedNote, error)
+	SaveLink(ctx context.Context, link CreateLink) error
+	GetLinkByTokenHash(ctx context.Context, tokenHash string) (models.NoteLink, error)
+	GetLinksByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteLink, error)
+	DeleteLink(ctx context.Context, noteID, linkID uuid.UUID) error
+	SaveAttachment(ctx context.Context, attachment CreateAttachment) error
+	GetAttachment(ctx context.Context, noteID, id uuid.UUID) (models.Attachment, error)
+	GetAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.Attachment, error)
+	DeleteAttachment(ctx context.Context, noteID, id uuid.UUID) error
+	LockStorageQuota(ctx context.Context, ownerID uuid.UUID) error
+	GetUsedStorage(ctx context.Context, ownerID uuid.UUID) (int64, error)
+	GetAttachmentKeysByNoteID(ctx context.Context, noteID uuid.UUID) ([]string, error)
+	GetTrashedAttachmentKeys(ctx context.Context, trashedBefore time.Time) ([]string, error)
+	ExportNotes(ctx context.Context, authorID uuid.UUID, fn func(note models.ExportedNote) error) error
+	CreateImportJob(ctx context.Context, job CreateImportJob) error
+	GetImportJob(ctx context.Context, id uuid.UUID) (models.ImportJob, error)
+	ClaimImportJob(ctx context.Context, staleBefore, now time.Time) (models.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job UpdateImportJob) error
+	SaveImportedNote(ctx context.Context, note CreateImportedNote) (bool, error)
+	GetNoteChanges(ctx context.Context, authorID uuid.UUID, since int64, limit uint64) ([]models.NoteChange, error)
+	IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+type NoteCache interface {
+	GetNote(
+		ctx context.Context,
+		id uuid.UUID,
+		load func(ctx context.Context) (models.NoteOutput, error),
+	) (models.NoteOutput, error)
+	SetNote(ctx context.Context, note models.NoteOutput)
+	GetNotes(
+		ctx context.Context,
+		authorID uuid.UUID,
+		query string,
+		load func(ctx context.Context) ([]models.NoteOutput, error),
+	) ([]models.NoteOutput, error)
+	InvalidateNotes(ctx context.Context, authorID uuid.UUID, ids ...uuid.UUID)
+	GetRenderedNote(
+		ctx context.Context,
+		id uuid.UUID,
+		version int64,
+		render func(ctx context.Context) (models.RenderedNote, error),
+	) (models.RenderedNote, error)
 }
 
 type NoteService struct {
 	storage NoteStorage
-	cache   *redis.Client
+	cache   NoteCache
 }
 
 func (s *NoteService) SaveNoteByID(ctx context.Context, note CreateNote) error {
-	if err := s.storage.CreateNoteByID(ctx, note); err != nil {
+	version, err := s.storage.CreateNoteByID(ctx, note)
+	if err != nil {
 		return err
 	}
 
-	noteJSON, _ := json.Marshal(note)
-	if err := s.cache.Set(ctx, note.ID.String(), noteJSON, ExpirationTime).Err(); err != nil {
+	transactor.AfterCommit(ctx, func() {
+		s.cache.InvalidateNotes(ctx, note.Author)
+		s.cache.SetNote(ctx, models.NoteOutput{
+			ID:         note.ID,
+			Title:      note.Title,
+			Body:       note.Body,
+			Tags:       note.Tags,
+			Author:     note.Author,
+			CreatedAt:  note.CreatedAt,
+			UpdatedAt:  note.UpdatedAt,
+			NotebookID: note.NotebookID,
+			Version:    version,
+		})
+	})
+
+	return nil
+}
+
+func (s *NoteService) GetNoteByID(ctx context.Context, id uuid.UUID) (*models.NoteOutput, error) {
+	note, err := s.cache.GetNote(ctx, id, func(ctx context.Context) (models.NoteOutput, error) {
+		return s.storage.GetNoteByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// GetRenderedNote returns the rendering of the given version of the note,
+// calling render only when it isn't cached yet.
+func (s *NoteService) GetRenderedNote(
+	ctx context.Context,
+	id uuid.UUID,
+	version int64,
+	render func(ctx context.Context) (models.RenderedNote, error),
+) (models.RenderedNote, error) {
+	return s.cache.GetRenderedNote(ctx, id, version, render)
+}
+
+func (s *NoteService) GetAllNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.NoteOutput, error) {
+	return s.storage.GetAllNotesByAuthorID(ctx, authorID)
+}
+
+func (s *NoteService) GetNotesByQuery(ctx context.Context, query NotesQuery) ([]models.NoteOutput, error) {
+	load := func(ctx context.Context) ([]models.NoteOutput, error) {
+		return s.storage.GetNotesByQuery(ctx, query)
+	}
+
+	key, err := json.Marshal(query)
+	if err != nil {
+		return load(ctx)
+	}
+
+	sum := sha256.Sum256(key)
+
+	return s.cache.GetNotes(ctx, query.AuthorID, hex.EncodeToString(sum[:]), load)
+}
+
+func (s *NoteService) SearchNotes(ctx context.Context, query SearchQuery) ([]models.NoteSearchResult, error) {
+	return s.storage.SearchNotes(ctx, query)
+}
+
+func (s *NoteService) UpdateNoteByID(ctx context.Context, id, authorID uuid.UUID, note UpdateNote) (int64, error) {
+	version, err := s.storage.UpdateNoteByID(ctx, id, note)
+	if err != nil {
+		return 0, err
+	}
+
+	s.invalidate(ctx, authorID, id)
+
+	return version, nil
+}
+
+func (s *NoteService) DeleteNoteByID(
+	ctx context.Context,
+	id, authorID uuid.UUID,
+	deletedAt time.Time,
+	expectedVersion *int64,
+) error {
+	if err := s.storage.DeleteNoteByID(ctx, id, deletedAt, expectedVersion); err != nil {
 		return err
 	}
 
+	s.invalidate(ctx, authorID, id)
+
 	return nil
 }
 
-func (s *NoteService) GetNoteByID(ctx context.Context, id uuid.UUID) (*models.NoteOutput, error) {
-	var note models.NoteOutput
-
-	cachedNote, err := s.cache.Get(ctx, id.String()).Result()
-	if err == nil {
-		if err := json.Unmarshal([]byte(cachedNote), &note); err != nil {
-			logrus.Printf("error while unmarshaling cached data: %v", err)
-		}
-		return &note, nil
+func (s *NoteService) MoveNoteByID(
+	ctx context.Context,
+	id, authorID uuid.UUID,
+	notebookID *uuid.UUID,
+	updatedAt time.Time,
+) error {
+	if err := s.storage.MoveNoteByID(ctx, id, notebookID, updatedAt); err != nil {
+		return err
 	}
 
-	// Получаем данные из базы данных
-	note, err = s.storage.GetNoteByID(ctx, id)
+	s.invalidate(ctx, authorID, id)
+
+	return nil
+}
+
+// invalidate drops the notes and their author's lists from the cache once
+// the change is committed. Doing it earlier would let a concurrent read
+// cache the old state again.
+func (s *NoteService) invalidate(ctx context.Context, authorID uuid.UUID, ids ...uuid.UUID) {
+	transactor.AfterCommit(ctx, func() {
+		s.cache.InvalidateNotes(ctx, authorID, ids...)
+	})
+}
+
+func (s *NoteService) GetNotebookAuthorID(ctx context.Context, notebookID uuid.UUID) (uuid.UUID, error) {
+	return s.storage.GetNotebookAuthorID(ctx, notebookID)
+}
+
+func (s *NoteService) GetTagCounts(ctx context.Context, authorID uuid.UUID) ([]models.TagCount, error) {
+	return s.storage.GetTagCounts(ctx, authorID)
+}
+
+// ReplaceTags returns the number of notes whose tags changed.
+func (s *NoteService) ReplaceTags(
+	ctx context.Context,
+	authorID uuid.UUID,
+	sources []string,
+	target string,
+	updatedAt time.Time,
+) (int64, error) {
+	noteIDs, err := s.storage.ReplaceTags(ctx, authorID, sources, target, updatedAt)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// Если кеш доступен, пытаемся сохранить данные в нем
-	if s.cache != nil {
-		noteJSON, _ := json.Marshal(note)
-		err = s.cache.Set(ctx, id.String(), noteJSON, ExpirationTime).Err()
-		if err != nil {
-			logrus.Printf("error while saving to Redis: %v", err)
-		}
+	if len(noteIDs) > 0 {
+		s.invalidate(ctx, authorID, noteIDs...)
 	}
 
-	return &note, nil
+	return int64(len(noteIDs)), nil
 }
 
-func (s *NoteService) GetAllNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.NoteOutput, error) {
-	return s.storage.GetAllNotesByAuthorID(ctx, authorID)
+func (s *NoteService) GetTrashedNoteByID(ctx context.Context, id uuid.UUID) (models.TrashedNote, error) {
+	return s.storage.GetTrashedNoteByID(ctx, id)
+}
+
+func (s *NoteService) GetTrashedNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.TrashedNote, error) {
+	return s.storage.GetTrashedNotesByAuthorID(ctx, authorID)
+}
+
+func (s *NoteService) RestoreNoteByID(ctx context.Context, id, authorID uuid.UUID) error {
+	if err := s.storage.RestoreNoteByID(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, authorID, id)
+
+	return nil
+}
+
+func (s *NoteService) PurgeNoteByID(ctx context.Context, id uuid.UUID) error {
+	return s.storage.PurgeNoteByID(ctx, id)
+}
+
+func (s *NoteService) PurgeTrash(ctx context.Context, trashedBefore time.Time) (int64, error) {
+	return s.storage.PurgeTrash(ctx, trashedBefore)
+}
+
+func (s *NoteService) CreateRevision(ctx context.Context, noteID, revisionID uuid.UUID, createdAt time.Time) error {
+	return s.storage.CreateRevision(ctx, noteID, revisionID, createdAt)
+}
+
+func (s *NoteService) GetRevisionsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteRevision, error) {
+	return s.storage.GetRevisionsByNoteID(ctx, noteID)
+}
+
+func (s *NoteService) GetRevision(ctx context.Context, noteID uuid.UUID, number int) (models.NoteRevision, error) {
+	return s.storage.GetRevision(ctx, noteID, number)
+}
+
+func (s *NoteService) SaveShare(ctx context.Context, share CreateShare) error {
+	return s.storage.SaveShare(ctx, share)
+}
+
+func (s *NoteService) GetShareRole(ctx context.Context, noteID, userID uuid.UUID) (string, error) {
+	return s.storage.GetShareRole(ctx, noteID, userID)
+}
+
+func (s *NoteService) GetSharesByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteShare, error) {
+	return s.storage.GetSharesByNoteID(ctx, noteID)
+}
+
+func (s *NoteService) DeleteShare(ctx context.Context, noteID, userID uuid.UUID) error {
+	return s.storage.DeleteShare(ctx, noteID, userID)
+}
+
+func (s *NoteService) GetNotesSharedWithUser(ctx context.Context, userID uuid.UUID) ([]models.SharedNote, error) {
+	return s.storage.GetNotesSharedWithUser(ctx, userID)
+}
+
+func (s *NoteService) SaveLink(ctx context.Context, link CreateLink) error {
+	return s.storage.SaveLink(ctx, link)
+}
+
+func (s *NoteService) GetLinkByTokenHash(ctx context.Context, tokenHash string) (models.NoteLink, error) {
+	return s.storage.GetLinkByTokenHash(ctx, tokenHash)
+}
+
+func (s *NoteService) GetLinksByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteLink, error) {
+	return s.storage.GetLinksByNoteID(ctx, noteID)
 }
 
-func (s *NoteService) UpdateNoteByID(ctx context.Context, id uuid.UUID, note UpdateNote) error {
-	return s.storage.UpdateNoteByID(ctx, id, note)
+func (s *NoteService) DeleteLink(ctx context.Context, noteID, linkID uuid.UUID) error {
+	return s.storage.DeleteLink(ctx, noteID, linkID)
 }
 
-func (s *NoteService) DeleteNoteByID(ctx context.Context, id uuid.UUID) error {
-	return s.storage.DeleteNoteByID(ctx, id)
+func (s *NoteService) SaveAttachment(ctx context.Context, attachment CreateAttachment) error {
+	return s.storage.SaveAttachment(ctx, attachment)
 }
 
-func NewNoteService(storage NoteStorage, client *redis.Client) *NoteService {
+func (s *NoteService) GetAttachment(ctx context.Context, noteID, id uuid.UUID) (models.Attachment, error) {
+	return s.storage.GetAttachment(ctx, noteID, id)
+}
+
+func (s *NoteService) GetAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.Attachment, error) {
+	return s.storage.GetAttachmentsByNoteID(ctx, noteID)
+}
+
+func (s *NoteService) DeleteAttachment(ctx context.Context, noteID, id uuid.UUID) error {
+	return s.storage.DeleteAttachment(ctx, noteID, id)
+}
+
+func (s *NoteService) LockStorageQuota(ctx context.Context, ownerID uuid.UUID) error {
+	return s.storage.LockStorageQuota(ctx, ownerID)
+}
+
+func (s *NoteService) GetUsedStorage(ctx context.Context, ownerID uuid.UUID) (int64, error) {
+	return s.storage.GetUsedStorage(ctx, ownerID)
+}
+
+func (s *NoteService) GetAttachmentKeysByNoteID(ctx context.Context, noteID uuid.UUID) ([]string, error) {
+	return s.storage.GetAttachmentKeysByNoteID(ctx, noteID)
+}
+
+func (s *NoteService) GetTrashedAttachmentKeys(ctx context.Context, trashedBefore time.Time) ([]string, error) {
+	return s.storage.GetTrashedAttachmentKeys(ctx, trashedBefore)
+}
+
+func (s *NoteService) ExportNotes(
+	ctx context.Context,
+	authorID uuid.UUID,
+	fn func(note models.ExportedNote) error,
+) error {
+	return s.storage.ExportNotes(ctx, authorID, fn)
+}
+
+func (s *NoteService) CreateImportJob(ctx context.Context, job CreateImportJob) error {
+	return s.storage.CreateImportJob(ctx, job)
+}
+
+func (s *NoteService) GetImportJob(ctx context.Context, id uuid.UUID) (models.ImportJob, error) {
+	return s.storage.GetImportJob(ctx, id)
+}
+
+func (s *NoteService) ClaimImportJob(ctx context.Context, staleBefore, now time.Time) (models.ImportJob, error) {
+	return s.storage.ClaimImportJob(ctx, staleBefore, now)
+}
+
+func (s *NoteService) UpdateImportJob(ctx context.Context, job UpdateImportJob) error {
+	return s.storage.UpdateImportJob(ctx, job)
+}
+
+func (s *NoteService) SaveImportedNote(ctx context.Context, note CreateImportedNote) (bool, error) {
+	return s.storage.SaveImportedNote(ctx, note)
+}
+
+func NewNoteService(storage NoteStorage, cache NoteCache) *NoteService {
 	return &NoteService{
 		storage: storage,
-		cache:   client,
+		cache:   cache,
 	}
 }
+
+func (s *NoteService) GetNoteChanges(
+	ctx context.Context,
+	authorID uuid.UUID,
+	since int64,
+	limit uint64,
+) ([]models.NoteChange, error) {
+	return s.storage.GetNoteChanges(ctx, authorID, since, limit)
+}
+
+func (s *NoteService) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return s.storage.IsEmailVerified(ctx, userID)
+}
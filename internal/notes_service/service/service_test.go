@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+// stubStorage stores created notes the way the database does, starting them
+// at version 1. Other storage calls are not expected.
+type stubStorage struct {
+	NoteStorage
+	notes map[uuid.UUID]models.NoteOutput
+}
+
+func (s *stubStorage) CreateNoteByID(_ context.Context, note CreateNote) (int64, error) {
+	s.notes[note.ID] = models.NoteOutput{
+		ID:         note.ID,
+		Title:      note.Title,
+		Body:       note.Body,
+		Tags:       note.Tags,
+		Author:     note.Author,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+		NotebookID: note.NotebookID,
+		Version:    1,
+	}
+
+	return 1, nil
+}
+
+// mapCache keeps notes in a map. Other cache calls are not expected.
+type mapCache struct {
+	NoteCache
+	notes map[uuid.UUID]models.NoteOutput
+}
+
+func (c *mapCache) GetNote(
+	ctx context.Context,
+	id uuid.UUID,
+	load func(ctx context.Context) (models.NoteOutput, error),
+) (models.NoteOutput, error) {
+	if note, ok := c.notes[id]; ok {
+		return note, nil
+	}
+
+	return load(ctx)
+}
+
+func (c *mapCache) SetNote(_ context.Context, note models.NoteOutput) {
+	c.notes[note.ID] = note
+}
+
+func (c *mapCache) InvalidateNotes(context.Context, uuid.UUID, ...uuid.UUID) {}
+
+func TestSaveNoteByIDCachesStoredVersion(t *testing.T) {
+	ctx := context.Background()
+	cache := &mapCache{notes: make(map[uuid.UUID]models.NoteOutput)}
+	s := NewNoteService(&stubStorage{notes: make(map[uuid.UUID]models.NoteOutput)}, cache)
+
+	now := time.Now().UTC()
+	note := NewCreateNote(uuid.New(), "Title", "Body", []string{"tag"}, uuid.New(), now, now)
+
+	if err := s.SaveNoteByID(ctx, note); err != nil {
+		t.Fatalf("SaveNoteByID: %v", err)
+	}
+
+	if _, ok := cache.notes[note.ID]; !ok {
+		t.Fatal("created note was not cached")
+	}
+
+	got, err := s.GetNoteByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID: %v", err)
+	}
+
+	if got.Version != 1 {
+		t.Errorf("cached note has version %d, want 1", got.Version)
+	}
+}
+
+func TestSaveNoteByIDDoesNotCacheFailedNote(t *testing.T) {
+	ctx := context.Background()
+	cache := &mapCache{notes: make(map[uuid.UUID]models.NoteOutput)}
+	s := NewNoteService(failingStorage{}, cache)
+
+	now := time.Now().UTC()
+	note := NewCreateNote(uuid.New(), "Title", "Body", nil, uuid.New(), now, now)
+
+	if err := s.SaveNoteByID(ctx, note); !errors.Is(err, models.ErrNoteExists) {
+		t.Fatalf("SaveNoteByID error = %v, want %v", err, models.ErrNoteExists)
+	}
+
+	if _, ok := cache.notes[note.ID]; ok {
+		t.Error("note that failed to save was cached")
+	}
+}
+
+type failingStorage struct {
+	NoteStorage
+}
+
+func (failingStorage) CreateNoteByID(context.Context, CreateNote) (int64, error) {
+	return 0, models.ErrNoteExists
+}
@@ -6,11 +6,13 @@ import (
 )
 
 type NoteResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	Body      string    `json:"body"`
-	Tags      []string  `json:"tags"`
-	Author    uuid.UUID `json:"author"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	Tags       []string   `json:"tags"`
+	Author     uuid.UUID  `json:"author"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	NotebookID *uuid.UUID `json:"notebook_id,omitempty"`
+	Version    int64      `json:"version"`
 }
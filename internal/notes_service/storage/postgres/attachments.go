@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+func (s *NoteStorage) SaveAttachment(ctx context.Context, attachment service.CreateAttachment) error {
+	sql, args, err := squirrel.Insert("attachments").
+		Columns("id", "note_id", "owner_id", "filename", "content_type", "size", "storage_key", "created_at").
+		Values(
+			attachment.ID, attachment.NoteID, attachment.OwnerID, attachment.Filename,
+			attachment.ContentType, attachment.Size, attachment.StorageKey, attachment.CreatedAt,
+		).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NoteStorage) GetAttachment(ctx context.Context, noteID, id uuid.UUID) (models.Attachment, error) {
+	sql, args, err := squirrel.Select("id", "note_id", "filename", "content_type", "size", "created_at", "storage_key").
+		From("attachments").
+		Where(squirrel.Eq{"id": id, "note_id": noteID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	var attachment models.Attachment
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&attachment.ID, &attachment.NoteID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.CreatedAt, &attachment.StorageKey,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Attachment{}, models.ErrAttachmentNotFound
+		}
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (s *NoteStorage) GetAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.Attachment, error) {
+	sql, args, err := squirrel.Select("id", "note_id", "filename", "content_type", "size", "created_at", "storage_key").
+		From("attachments").
+		Where(squirrel.Eq{"note_id": noteID}).
+		OrderBy("created_at", "id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		var attachment models.Attachment
+		err = rows.Scan(
+			&attachment.ID, &attachment.NoteID, &attachment.Filename, &attachment.ContentType,
+			&attachment.Size, &attachment.CreatedAt, &attachment.StorageKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (s *NoteStorage) DeleteAttachment(ctx context.Context, noteID, id uuid.UUID) error {
+	sql, args, err := squirrel.Delete("attachments").
+		Where(squirrel.Eq{"id": id, "note_id": noteID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+// LockStorageQuota serializes the quota checks of one user until the
+// transaction ends, so that parallel uploads can't overrun the quota.
+func (s *NoteStorage) LockStorageQuota(ctx context.Context, ownerID uuid.UUID) error {
+	_, err := s.conn(ctx).Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`, ownerID)
+	return err
+}
+
+// GetUsedStorage returns the total size of the files attached to the notes
+// of the user, trashed notes included.
+func (s *NoteStorage) GetUsedStorage(ctx context.Context, ownerID uuid.UUID) (int64, error) {
+	sql, args, err := squirrel.Select("coalesce(sum(size), 0)").
+		From("attachments").
+		Where(squirrel.Eq{"owner_id": ownerID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var used int64
+
+	if err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&used); err != nil {
+		return 0, err
+	}
+
+	return used, nil
+}
+
+// GetAttachmentKeysByNoteID returns the blob keys of the note's attachments.
+// The note is locked until the transaction ends, so that it can be purged
+// together with its blobs.
+func (s *NoteStorage) GetAttachmentKeysByNoteID(ctx context.Context, noteID uuid.UUID) ([]string, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT a.storage_key FROM attachments a
+		JOIN notes n ON n.id = a.note_id
+		WHERE n.id = $1
+		FOR UPDATE OF n`, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// GetTrashedAttachmentKeys returns the blob keys of the attachments of notes
+// trashed before the given moment, locking those notes like
+// GetAttachmentKeysByNoteID does.
+func (s *NoteStorage) GetTrashedAttachmentKeys(ctx context.Context, trashedBefore time.Time) ([]string, error) {
+	rows, err := s.conn(ctx).Query(ctx, `
+		SELECT a.storage_key FROM attachments a
+		JOIN notes n ON n.id = a.note_id
+		WHERE n.deleted_at < $1
+		FOR UPDATE OF n`, trashedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+// exportBatchSize is how many notes ExportNotes reads per query. Reading in
+// batches keeps memory flat and never holds a statement open while the
+// caller writes a slow response.
+const exportBatchSize = 100
+
+// exportNotes selects a batch of the author's notes, after the note at
+// ($2, $3), with the path of their notebooks and their attachments.
+const exportNotes = `WITH RECURSIVE folders AS (
+    SELECT id, ARRAY[name] AS path FROM notebooks WHERE author = $1 AND parent_id IS NULL
+    UNION ALL
+    SELECT nb.id, f.path || nb.name FROM notebooks nb JOIN folders f ON nb.parent_id = f.id
+)
+SELECT n.id, n.title, n.body, n.tags, n.author, n.created_at, n.updated_at, n.notebook_id, n.version,
+    coalesce(f.path, '{}'),
+    coalesce((
+        SELECT json_agg(json_build_object(
+            'id', a.id, 'filename', a.filename, 'content_type', a.content_type,
+            'size', a.size, 'storage_key', a.storage_key
+        ) ORDER BY a.created_at, a.id)
+        FROM attachments a WHERE a.note_id = n.id
+    ), '[]')
+FROM notes n
+LEFT JOIN folders f ON f.id = n.notebook_id
+WHERE n.author = $1 AND n.deleted_at IS NULL AND (n.created_at, n.id) > ($2, $3)
+ORDER BY n.created_at, n.id
+LIMIT $4`
+
+// exportedAttachment mirrors models.Attachment, whose storage key is hidden
+// from JSON.
+type exportedAttachment struct {
+	ID          uuid.UUID `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"storage_key"`
+}
+
+// ExportNotes calls fn with every note of the author that isn't in the
+// trash, oldest first, and stops at the first error fn returns.
+func (s *NoteStorage) ExportNotes(ctx context.Context, authorID uuid.UUID, fn func(note models.ExportedNote) error) error {
+	var (
+		afterCreatedAt time.Time
+		afterID        uuid.UUID
+	)
+
+	for {
+		notes, err := s.exportBatch(ctx, authorID, afterCreatedAt, afterID)
+		if err != nil {
+			return err
+		}
+
+		for _, note := range notes {
+			if err = fn(note); err != nil {
+				return err
+			}
+		}
+
+		if len(notes) < exportBatchSize {
+			return nil
+		}
+
+		last := notes[len(notes)-1]
+		afterCreatedAt, afterID = last.CreatedAt, last.ID
+	}
+}
+
+func (s *NoteStorage) exportBatch(
+	ctx context.Context,
+	authorID uuid.UUID,
+	afterCreatedAt time.Time,
+	afterID uuid.UUID,
+) ([]models.ExportedNote, error) {
+	rows, err := s.conn(ctx).Query(ctx, exportNotes, authorID, afterCreatedAt, afterID, exportBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make([]models.ExportedNote, 0, exportBatchSize)
+	for rows.Next() {
+		var (
+			note        models.ExportedNote
+			attachments []exportedAttachment
+		)
+
+		err = rows.Scan(
+			&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt,
+			&note.NotebookID, &note.Version, &note.Folder, &attachments,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attachment := range attachments {
+			note.Attachments = append(note.Attachments, models.Attachment{
+				ID:          attachment.ID,
+				NoteID:      note.ID,
+				Filename:    attachment.Filename,
+				ContentType: attachment.ContentType,
+				Size:        attachment.Size,
+				StorageKey:  attachment.StorageKey,
+			})
+		}
+
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const importJobColumns = `id, owner_id, format, status, source_key, created_count, skipped_count, failed_count,
+    failures, error, created_at, updated_at, finished_at`
+
+func (s *NoteStorage) CreateImportJob(ctx context.Context, job service.CreateImportJob) error {
+	sql, args, err := squirrel.Insert("import_jobs").
+		Columns("id", "owner_id", "format", "status", "source_key", "created_at", "updated_at").
+		Values(job.ID, job.OwnerID, job.Format, models.ImportPending, job.SourceKey, job.CreatedAt, job.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NoteStorage) GetImportJob(ctx context.Context, id uuid.UUID) (models.ImportJob, error) {
+	row := s.conn(ctx).QueryRow(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE id = $1`, id)
+
+	return scanImportJob(row)
+}
+
+// ClaimImportJob marks the oldest pending job as running and returns it.
+// Running jobs that made no progress since staleBefore are taken over too:
+// their worker is gone. Concurrent workers never claim the same job.
+func (s *NoteStorage) ClaimImportJob(ctx context.Context, staleBefore, now time.Time) (models.ImportJob, error) {
+	row := s.conn(ctx).QueryRow(ctx, `
+		UPDATE import_jobs
+		SET status = $1, updated_at = $2, created_count = 0, skipped_count = 0, failed_count = 0, failures = '[]'
+		WHERE id = (
+		    SELECT id FROM import_jobs
+		    WHERE status = $3 OR (status = $1 AND updated_at < $4)
+		    ORDER BY created_at
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+importJobColumns,
+		models.ImportRunning, now, models.ImportPending, staleBefore)
+
+	return scanImportJob(row)
+}
+
+func (s *NoteStorage) UpdateImportJob(ctx context.Context, job service.UpdateImportJob) error {
+	failures := job.Failures
+	if failures == nil {
+		failures = []models.ImportFailure{}
+	}
+
+	sql, args, err := squirrel.Update("import_jobs").
+		Set("status", job.Status).
+		Set("created_count", job.Created).
+		Set("skipped_count", job.Skipped).
+		Set("failed_count", job.Failed).
+		Set("failures", failures).
+		Set("error", job.Error).
+		Set("updated_at", job.UpdatedAt).
+		Set("finished_at", job.FinishedAt).
+		Where(squirrel.Eq{"id": job.ID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrImportJobNotFound
+	}
+
+	return nil
+}
+
+// SaveImportedNote remembers that an item has been imported as the note. It
+// reports false, saving nothing, when the owner imported the item before.
+func (s *NoteStorage) SaveImportedNote(ctx context.Context, note service.CreateImportedNote) (bool, error) {
+	sql, args, err := squirrel.Insert("imported_notes").
+		Columns("owner_id", "item_key", "note_id", "job_id").
+		Values(note.OwnerID, note.ItemKey, note.NoteID, note.JobID).
+		Suffix("ON CONFLICT (owner_id, item_key) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func scanImportJob(row pgx.Row) (models.ImportJob, error) {
+	var job models.ImportJob
+
+	err := row.Scan(
+		&job.ID, &job.OwnerID, &job.Format, &job.Status, &job.SourceKey, &job.Created, &job.Skipped, &job.Failed,
+		&job.Failures, &job.Error, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ImportJob{}, models.ErrImportJobNotFound
+		}
+		return models.ImportJob{}, err
+	}
+
+	return job, nil
+}
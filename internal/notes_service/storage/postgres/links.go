@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+func (s *NoteStorage) SaveLink(ctx context.Context, link service.CreateLink) error {
+	sql, args, err := squirrel.Insert("note_links").
+		Columns("id", "note_id", "token_hash", "password_hash", "expires_at", "created_at").
+		Values(link.ID, link.NoteID, link.TokenHash, link.PasswordHash, link.ExpiresAt, link.CreatedAt).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NoteStorage) GetLinkByTokenHash(ctx context.Context, tokenHash string) (models.NoteLink, error) {
+	sql, args, err := squirrel.Select(
+		"id", "note_id", "coalesce(password_hash, '')", "expires_at", "created_at",
+	).
+		From("note_links").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.NoteLink{}, err
+	}
+
+	link, err := scanLink(s.conn(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.NoteLink{}, models.ErrLinkNotFound
+		}
+		return models.NoteLink{}, err
+	}
+
+	return link, nil
+}
+
+func (s *NoteStorage) GetLinksByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteLink, error) {
+	sql, args, err := squirrel.Select(
+		"id", "note_id", "coalesce(password_hash, '')", "expires_at", "created_at",
+	).
+		From("note_links").
+		Where(squirrel.Eq{"note_id": noteID}).
+		OrderBy("created_at DESC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []models.NoteLink
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (s *NoteStorage) DeleteLink(ctx context.Context, noteID, linkID uuid.UUID) error {
+	sql, args, err := squirrel.Delete("note_links").
+		Where(squirrel.Eq{"id": linkID, "note_id": noteID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func scanLink(row pgx.Row) (models.NoteLink, error) {
+	var link models.NoteLink
+
+	err := row.Scan(&link.ID, &link.NoteID, &link.PasswordHash, &link.ExpiresAt, &link.CreatedAt)
+	if err != nil {
+		return models.NoteLink{}, err
+	}
+
+	link.HasPassword = link.PasswordHash != ""
+
+	return link, nil
+}
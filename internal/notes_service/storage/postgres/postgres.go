@@ -2,50 +2,79 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"notes-rew/internal/db/transactor"
 	"notes-rew/internal/notes_service/models"
 	"notes-rew/internal/notes_service/service"
 	"notes-rew/internal/notes_service/storage"
 )
 
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+// escapedBody is the body escaped for HTML the way html.EscapeString does
+// it, so that search snippets carry no markup but that of the highlights.
+const escapedBody = `replace(replace(replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
 type NoteStorage struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
+}
+
+// conn returns the transaction carried by ctx, if any, or the pool.
+func (s *NoteStorage) conn(ctx context.Context) transactor.Querier {
+	return transactor.Conn(ctx, s.db)
 }
 
-func (s *NoteStorage) CreateNoteByID(ctx context.Context, note service.CreateNote) error {
+// CreateNoteByID saves a new note and returns the version it got.
+func (s *NoteStorage) CreateNoteByID(ctx context.Context, note service.CreateNote) (int64, error) {
 	sql, args, err := squirrel.Insert("notes").
-		Columns("id", "title", "body", "tags", "author", "created_at", "updated_at").
-		Values(note.ID, note.Title, note.Body, note.Tags, note.Author, note.CreatedAt, note.UpdatedAt).
+		Columns("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id").
+		Values(note.ID, note.Title, note.Body, note.Tags, note.Author, note.CreatedAt, note.UpdatedAt, note.NotebookID).
+		Suffix("RETURNING version").
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	var version int64
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&version)
 	if err != nil {
-		return err
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return 0, models.ErrNoteExists
+		}
+		return 0, err
 	}
 
-	return nil
+	return version, nil
 }
 
 func (s *NoteStorage) GetNoteByID(ctx context.Context, id uuid.UUID) (models.NoteOutput, error) {
 	var note storage.NoteResponse
 
-	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at").
+	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version").
 		From("notes").
-		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 
 	if err != nil {
 		return models.NoteOutput{}, err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.NoteOutput{}, models.ErrNoteNotFound
+		}
 		return models.NoteOutput{}, err
 	}
 
@@ -54,16 +83,16 @@ func (s *NoteStorage) GetNoteByID(ctx context.Context, id uuid.UUID) (models.Not
 }
 
 func (s *NoteStorage) GetAllNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.NoteOutput, error) {
-	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at").
+	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version").
 		From("notes").
-		Where(squirrel.Eq{"author": authorID}).
+		Where(squirrel.Eq{"author": authorID, "deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := s.db.Query(ctx, sql, args...)
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +101,7 @@ func (s *NoteStorage) GetAllNotesByAuthorID(ctx context.Context, authorID uuid.U
 	var notes []models.NoteOutput
 	for rows.Next() {
 		var note models.NoteOutput
-		err = rows.Scan(&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt)
+		err = rows.Scan(&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -86,47 +115,535 @@ func (s *NoteStorage) GetAllNotesByAuthorID(ctx context.Context, authorID uuid.U
 	return notes, nil
 }
 
-func (s *NoteStorage) UpdateNoteByID(ctx context.Context, id uuid.UUID, note service.UpdateNote) error {
+func (s *NoteStorage) GetNotesByQuery(ctx context.Context, query service.NotesQuery) ([]models.NoteOutput, error) {
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
 
-	sql, args, err := squirrel.Update("notes").
-		Set("title", note.Title).
-		Set("body", note.Body).
-		Set("tags", note.Tags).
+	builder := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version").
+		From("notes").
+		Where(squirrel.Eq{"author": query.AuthorID, "deleted_at": nil})
+
+	if len(query.Tags) > 0 {
+		builder = builder.Where("tags @> ?", query.Tags)
+	}
+
+	if query.CreatedFrom != nil {
+		builder = builder.Where(squirrel.GtOrEq{"created_at": *query.CreatedFrom})
+	}
+
+	if query.CreatedTo != nil {
+		builder = builder.Where(squirrel.LtOrEq{"created_at": *query.CreatedTo})
+	}
+
+	if query.After != nil {
+		builder = builder.Where(
+			fmt.Sprintf("(%s, id) %s (?, ?)", query.SortBy, comparison),
+			query.After.Value,
+			query.After.ID,
+		)
+	}
+
+	sql, args, err := builder.
+		OrderBy(query.SortBy+" "+direction, "id "+direction).
+		Limit(query.Limit).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make([]models.NoteOutput, 0, query.Limit)
+	for rows.Next() {
+		var note models.NoteOutput
+		err = rows.Scan(&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// SearchNotes ranks the author's notes against a web-style search query and
+// highlights the matching fragments of the body.
+func (s *NoteStorage) SearchNotes(ctx context.Context, query service.SearchQuery) ([]models.NoteSearchResult, error) {
+	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version").
+		Column("ts_rank(search_vector, query) AS rank").
+		Column("ts_headline('simple', "+escapedBody+", query, ?) AS snippet", searchHeadlineOptions).
+		From("notes").
+		JoinClause("CROSS JOIN websearch_to_tsquery('simple', ?) AS query", query.Query).
+		Where(squirrel.Eq{"author": query.AuthorID, "deleted_at": nil}).
+		Where("search_vector @@ query").
+		OrderBy("rank DESC", "updated_at DESC", "id").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.NoteSearchResult, 0, query.Limit)
+	for rows.Next() {
+		var result models.NoteSearchResult
+		err = rows.Scan(
+			&result.ID, &result.Title, &result.Body, &result.Tags, &result.Author, &result.CreatedAt, &result.UpdatedAt,
+			&result.NotebookID, &result.Version, &result.Rank, &result.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateNoteByID changes the non-nil fields of note and returns the new
+// version of the note.
+func (s *NoteStorage) UpdateNoteByID(ctx context.Context, id uuid.UUID, note service.UpdateNote) (int64, error) {
+	where := squirrel.Eq{"id": id, "deleted_at": nil}
+	if note.ExpectedVersion != nil {
+		where["version"] = *note.ExpectedVersion
+	}
+
+	builder := squirrel.Update("notes")
+
+	// Only the supplied fields change, so a partial update doesn't clobber
+	// the rest of the note.
+	if note.Title != nil {
+		builder = builder.Set("title", *note.Title)
+	}
+	if note.Body != nil {
+		builder = builder.Set("body", *note.Body)
+	}
+	if note.Tags != nil {
+		builder = builder.Set("tags", *note.Tags)
+	}
+
+	sql, args, err := builder.
 		Set("updated_at", note.UpdatedAt).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(where).
+		Suffix("RETURNING version").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, s.updateMissError(ctx, id, note.ExpectedVersion)
+		}
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// updateMissError tells apart a missing note from a version conflict after
+// a conditional update matched no row.
+func (s *NoteStorage) updateMissError(ctx context.Context, id uuid.UUID, expectedVersion *int64) error {
+	if expectedVersion == nil {
+		return models.ErrNoteNotFound
+	}
+
+	sql, args, err := squirrel.Select("version").
+		From("notes").
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	var current int64
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrNoteNotFound
+		}
+		return err
+	}
+
+	return &models.VersionConflictError{CurrentVersion: current}
+}
+
+// DeleteNoteByID moves the note to the trash. It stays there until it is
+// restored or purged. With expectedVersion set, only that version of the note
+// is deleted.
+func (s *NoteStorage) DeleteNoteByID(ctx context.Context, id uuid.UUID, deletedAt time.Time, expectedVersion *int64) error {
+	where := squirrel.Eq{"id": id, "deleted_at": nil}
+	if expectedVersion != nil {
+		where["version"] = *expectedVersion
+	}
+
+	sql, args, err := squirrel.Update("notes").
+		Set("deleted_at", deletedAt).
+		Where(where).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return s.updateMissError(ctx, id, expectedVersion)
+	}
+
+	return nil
+}
+
+// MoveNoteByID puts the note into a notebook, or takes it out of any notebook
+// when notebookID is nil.
+func (s *NoteStorage) MoveNoteByID(ctx context.Context, id uuid.UUID, notebookID *uuid.UUID, updatedAt time.Time) error {
+	sql, args, err := squirrel.Update("notes").
+		Set("notebook_id", notebookID).
+		Set("updated_at", updatedAt).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+func (s *NoteStorage) GetNotebookAuthorID(ctx context.Context, notebookID uuid.UUID) (uuid.UUID, error) {
+	var author uuid.UUID
+
+	sql, args, err := squirrel.Select("author").
+		From("notebooks").
+		Where(squirrel.Eq{"id": notebookID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&author)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, models.ErrNotebookNotFound
+		}
+		return uuid.Nil, err
+	}
+
+	return author, nil
+}
+
+func (s *NoteStorage) GetTrashedNoteByID(ctx context.Context, id uuid.UUID) (models.TrashedNote, error) {
+	var note models.TrashedNote
+
+	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version", "deleted_at").
+		From("notes").
 		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.TrashedNote{}, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version,
+		&note.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TrashedNote{}, models.ErrNoteNotFound
+		}
+		return models.TrashedNote{}, err
+	}
+
+	return note, nil
+}
+
+func (s *NoteStorage) GetTrashedNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.TrashedNote, error) {
+	sql, args, err := squirrel.Select("id", "title", "body", "tags", "author", "created_at", "updated_at", "notebook_id", "version", "deleted_at").
+		From("notes").
+		Where(squirrel.Eq{"author": authorID}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		OrderBy("deleted_at DESC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.TrashedNote
+	for rows.Next() {
+		var note models.TrashedNote
+		err = rows.Scan(
+			&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version,
+			&note.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+func (s *NoteStorage) RestoreNoteByID(ctx context.Context, id uuid.UUID) error {
+	sql, args, err := squirrel.Update("notes").
+		Set("deleted_at", nil).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return models.ErrNoteNotFound
+	}
+
 	return nil
 }
 
-func (s *NoteStorage) DeleteNoteByID(ctx context.Context, id uuid.UUID) error {
+// PurgeNoteByID permanently deletes a note that is already in the trash.
+func (s *NoteStorage) PurgeNoteByID(ctx context.Context, id uuid.UUID) error {
 	sql, args, err := squirrel.Delete("notes").
 		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return models.ErrNoteNotFound
+	}
+
 	return nil
 }
 
-func NewNoteStorage(db *pgx.Conn) *NoteStorage {
+// PurgeTrash permanently deletes every note trashed before the given moment.
+func (s *NoteStorage) PurgeTrash(ctx context.Context, trashedBefore time.Time) (int64, error) {
+	sql, args, err := squirrel.Delete("notes").
+		Where(squirrel.Lt{"deleted_at": trashedBefore}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// CreateRevision archives the current state of the note as its next revision.
+// It must run inside a transaction: the note stays locked until commit, so
+// concurrent updates of the note get consecutive revision numbers.
+func (s *NoteStorage) CreateRevision(ctx context.Context, noteID, revisionID uuid.UUID, createdAt time.Time) error {
+	// The lock is taken by a statement of its own because under read
+	// committed the insert below only sees revisions committed before it
+	// started, which must include those of whoever held the lock before.
+	sql, args, err := squirrel.Select("1").
+		From("notes").
+		Where(squirrel.Eq{"id": noteID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	var locked int
+	if err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&locked); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrNoteNotFound
+		}
+		return err
+	}
+
+	snapshot := squirrel.Select().
+		Column("?::uuid", revisionID).
+		Column("id").
+		Column(squirrel.Expr(
+			"coalesce((SELECT max(revision) FROM note_revisions WHERE note_id = ?), 0) + 1",
+			noteID,
+		)).
+		Columns("title", "body", "tags").
+		Column("?::timestamp", createdAt).
+		From("notes").
+		Where(squirrel.Eq{"id": noteID})
+
+	sql, args, err = squirrel.Insert("note_revisions").
+		Columns("id", "note_id", "revision", "title", "body", "tags", "created_at").
+		Select(snapshot).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (s *NoteStorage) GetRevisionsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteRevision, error) {
+	sql, args, err := squirrel.Select("id", "note_id", "revision", "title", "body", "tags", "created_at").
+		From("note_revisions").
+		Where(squirrel.Eq{"note_id": noteID}).
+		OrderBy("revision DESC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.NoteRevision
+	for rows.Next() {
+		var revision models.NoteRevision
+		err = rows.Scan(
+			&revision.ID, &revision.NoteID, &revision.Revision,
+			&revision.Title, &revision.Body, &revision.Tags, &revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (s *NoteStorage) GetRevision(ctx context.Context, noteID uuid.UUID, number int) (models.NoteRevision, error) {
+	var revision models.NoteRevision
+
+	sql, args, err := squirrel.Select("id", "note_id", "revision", "title", "body", "tags", "created_at").
+		From("note_revisions").
+		Where(squirrel.Eq{"note_id": noteID, "revision": number}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return models.NoteRevision{}, err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&revision.ID, &revision.NoteID, &revision.Revision,
+		&revision.Title, &revision.Body, &revision.Tags, &revision.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.NoteRevision{}, models.ErrRevisionNotFound
+		}
+		return models.NoteRevision{}, err
+	}
+
+	return revision, nil
+}
+
+func NewNoteStorage(db *pgxpool.Pool) *NoteStorage {
 	return &NoteStorage{
 		db: db,
 	}
 }
+
+func (s *NoteStorage) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	sql, args, err := squirrel.Select("email_verified_at IS NOT NULL").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var verified bool
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&verified)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, models.ErrUserNotFound
+		}
+		return false, err
+	}
+
+	return verified, nil
+}
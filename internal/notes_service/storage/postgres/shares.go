@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// SaveShare grants a user a role on a note, replacing the role they already had.
+func (s *NoteStorage) SaveShare(ctx context.Context, share service.CreateShare) error {
+	sql, args, err := squirrel.Insert("note_shares").
+		Columns("note_id", "user_id", "role", "created_at").
+		Values(share.NoteID, share.UserID, share.Role, share.CreatedAt).
+		Suffix("ON CONFLICT (note_id, user_id) DO UPDATE SET role = EXCLUDED.role").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return models.ErrUserNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *NoteStorage) GetShareRole(ctx context.Context, noteID, userID uuid.UUID) (string, error) {
+	var role string
+
+	sql, args, err := squirrel.Select("role").
+		From("note_shares").
+		Where(squirrel.Eq{"note_id": noteID, "user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return "", err
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", models.ErrShareNotFound
+		}
+		return "", err
+	}
+
+	return role, nil
+}
+
+func (s *NoteStorage) GetSharesByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteShare, error) {
+	sql, args, err := squirrel.Select("note_id", "user_id", "role", "created_at").
+		From("note_shares").
+		Where(squirrel.Eq{"note_id": noteID}).
+		OrderBy("created_at").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []models.NoteShare
+	for rows.Next() {
+		var share models.NoteShare
+		if err = rows.Scan(&share.NoteID, &share.UserID, &share.Role, &share.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func (s *NoteStorage) DeleteShare(ctx context.Context, noteID, userID uuid.UUID) error {
+	sql, args, err := squirrel.Delete("note_shares").
+		Where(squirrel.Eq{"note_id": noteID, "user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrShareNotFound
+	}
+
+	return nil
+}
+
+// GetNotesSharedWithUser lists the notes other users shared with userID,
+// leaving out the ones that are in the trash.
+func (s *NoteStorage) GetNotesSharedWithUser(ctx context.Context, userID uuid.UUID) ([]models.SharedNote, error) {
+	sql, args, err := squirrel.Select(
+		"n.id", "n.title", "n.body", "n.tags", "n.author", "n.created_at", "n.updated_at", "n.notebook_id", "n.version", "s.role",
+	).
+		From("note_shares s").
+		Join("notes n ON n.id = s.note_id").
+		Where(squirrel.Eq{"s.user_id": userID, "n.deleted_at": nil}).
+		OrderBy("s.created_at DESC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.SharedNote
+	for rows.Next() {
+		var note models.SharedNote
+		err = rows.Scan(
+			&note.ID, &note.Title, &note.Body, &note.Tags, &note.Author, &note.CreatedAt, &note.UpdatedAt, &note.NotebookID, &note.Version, &note.Role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
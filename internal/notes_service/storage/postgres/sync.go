@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+// noteChanges selects the author's notes and tombstones whose change
+// sequence number is above $2, in the order the changes were made.
+const noteChanges = `SELECT id, title, body, tags, author, created_at, updated_at, notebook_id, version, deleted_at, change_seq
+FROM notes
+WHERE author = $1 AND change_seq > $2
+UNION ALL
+SELECT note_id, '', '', NULL::text[], author, deleted_at, deleted_at, NULL::uuid, 0::bigint, deleted_at, change_seq
+FROM note_tombstones
+WHERE author = $1 AND change_seq > $2
+ORDER BY change_seq
+LIMIT $3`
+
+// GetNoteChanges returns up to limit changes made to the author's notes
+// after the change numbered since, oldest first. Every note appears once,
+// as it stands after its latest change.
+func (s *NoteStorage) GetNoteChanges(
+	ctx context.Context,
+	authorID uuid.UUID,
+	since int64,
+	limit uint64,
+) ([]models.NoteChange, error) {
+	rows, err := s.conn(ctx).Query(ctx, noteChanges, authorID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.NoteChange
+	for rows.Next() {
+		var change models.NoteChange
+
+		err = rows.Scan(
+			&change.Note.ID, &change.Note.Title, &change.Note.Body, &change.Note.Tags, &change.Note.Author,
+			&change.Note.CreatedAt, &change.Note.UpdatedAt, &change.Note.NotebookID, &change.Note.Version,
+			&change.DeletedAt, &change.Seq,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"notes-rew/internal/notes_service/models"
+)
+
+// replaceTagsExpr swaps every tag found in the first argument for the second
+// one, dropping the duplicates this creates and keeping the original order.
+const replaceTagsExpr = `(
+    SELECT array_agg(tag ORDER BY first_position)
+    FROM (SELECT CASE WHEN raw = ANY (?::text[]) THEN ?::text ELSE raw END AS tag,
+                 min(position)                                            AS first_position
+          FROM unnest(tags) WITH ORDINALITY AS t(raw, position)
+          GROUP BY 1) replaced
+)`
+
+// GetTagCounts counts the author's notes per tag, leaving out trashed notes.
+func (s *NoteStorage) GetTagCounts(ctx context.Context, authorID uuid.UUID) ([]models.TagCount, error) {
+	sql, args, err := squirrel.Select("tag", "count(*)").
+		From("notes").
+		JoinClause("CROSS JOIN unnest(tags) AS tag").
+		Where(squirrel.Eq{"author": authorID, "deleted_at": nil}).
+		GroupBy("tag").
+		OrderBy("count(*) DESC", "tag").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var tag models.TagCount
+		if err = rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// ReplaceTags replaces the sources tags with target on all of the author's
+// notes, trashed ones included, and returns the IDs of the changed notes.
+func (s *NoteStorage) ReplaceTags(
+	ctx context.Context,
+	authorID uuid.UUID,
+	sources []string,
+	target string,
+	updatedAt time.Time,
+) ([]uuid.UUID, error) {
+	sql, args, err := squirrel.Update("notes").
+		Set("tags", squirrel.Expr(replaceTagsExpr, sources, target)).
+		Set("updated_at", updatedAt).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"author": authorID}).
+		Where("tags && ?::text[]", sources).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
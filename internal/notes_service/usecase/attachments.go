@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const maxFilenameLength = 255
+
+var (
+	ErrAttachmentTooLarge        = errors.New("attachment is too large")
+	ErrQuotaExceeded             = errors.New("storage quota exceeded")
+	ErrUnsupportedAttachmentType = errors.New("only images and PDF files can be attached")
+)
+
+// attachmentTypes are the content types accepted for attachments. SVG is
+// left out on purpose: it can carry scripts.
+var attachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// AddAttachment stores a file and attaches it to the note. The content type
+// is sniffed from the content rather than trusted from the client. The size
+// counts against the quota of the note's author, whoever uploads it.
+func (u *NoteUsecase) AddAttachment(
+	ctx context.Context,
+	noteID, currentUserID uuid.UUID,
+	req AddAttachmentInput,
+) (*models.Attachment, error) {
+	note, err := u.authorize(ctx, noteID, currentUserID, accessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	content := bufio.NewReaderSize(req.Content, 512)
+	head, err := content.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(head)
+	if !attachmentTypes[contentType] {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	attachment := service.CreateAttachment{
+		ID:          uuid.New(),
+		NoteID:      noteID,
+		OwnerID:     note.Author,
+		Filename:    cleanFilename(req.Filename),
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+	attachment.StorageKey = noteID.String() + "/" + attachment.ID.String()
+
+	// One byte over the limit is enough to tell that the file is too large.
+	size, err := u.blobs.Put(ctx, attachment.StorageKey, io.LimitReader(content, u.limits.MaxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	attachment.Size = size
+
+	err = u.saveAttachment(ctx, attachment)
+	if err != nil {
+		u.deleteBlobs(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	return &models.Attachment{
+		ID:          attachment.ID,
+		NoteID:      attachment.NoteID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt,
+		StorageKey:  attachment.StorageKey,
+	}, nil
+}
+
+// saveAttachment records an uploaded attachment if it fits the limits. The
+// quota of the owner stays locked from the check to the insert, so parallel
+// uploads can't overrun it together.
+func (u *NoteUsecase) saveAttachment(ctx context.Context, attachment service.CreateAttachment) error {
+	if attachment.Size > u.limits.MaxAttachmentSize {
+		return ErrAttachmentTooLarge
+	}
+
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.LockStorageQuota(ctx, attachment.OwnerID); err != nil {
+			return err
+		}
+
+		used, err := u.service.GetUsedStorage(ctx, attachment.OwnerID)
+		if err != nil {
+			return err
+		}
+
+		if used+attachment.Size > u.limits.AttachmentQuota {
+			return ErrQuotaExceeded
+		}
+
+		return u.service.SaveAttachment(ctx, attachment)
+	})
+}
+
+func (u *NoteUsecase) ReadAttachments(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.Attachment, error) {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessRead); err != nil {
+		return nil, err
+	}
+
+	return u.service.GetAttachmentsByNoteID(ctx, noteID)
+}
+
+// OpenAttachment returns the attachment with a reader of its content, which
+// the caller must close.
+func (u *NoteUsecase) OpenAttachment(
+	ctx context.Context,
+	noteID, attachmentID, currentUserID uuid.UUID,
+) (*models.Attachment, io.ReadSeekCloser, error) {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessRead); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := u.service.GetAttachment(ctx, noteID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := u.blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &attachment, content, nil
+}
+
+func (u *NoteUsecase) DeleteAttachment(ctx context.Context, noteID, attachmentID, currentUserID uuid.UUID) error {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessWrite); err != nil {
+		return err
+	}
+
+	attachment, err := u.service.GetAttachment(ctx, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err = u.service.DeleteAttachment(ctx, noteID, attachmentID); err != nil {
+		return err
+	}
+
+	u.deleteBlobs(ctx, attachment.StorageKey)
+
+	return nil
+}
+
+// deleteBlobs removes blobs whose rows are already gone. A failure only
+// leaves an orphaned file behind, so it is logged rather than returned.
+func (u *NoteUsecase) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := u.blobs.Delete(ctx, key); err != nil {
+			logrus.Printf("error while deleting blob %s: %v", key, err)
+		}
+	}
+}
+
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+
+	if len(name) > maxFilenameLength {
+		name = strings.ToValidUTF8(name[:maxFilenameLength], "")
+	}
+
+	return name
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByTitle     = "title"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	DefaultNotesLimit = 20
+	MaxNotesLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("sort must be one of created_at, updated_at, title")
+	ErrInvalidOrder  = errors.New("order must be asc or desc")
+	ErrInvalidLimit  = errors.New("limit must be between 1 and 100")
+)
+
+// notesCursor is serialized into the opaque next_cursor token. It remembers
+// the ordering it was issued for, so it can't be replayed against another one.
+type notesCursor struct {
+	SortBy string    `json:"s"`
+	Order  string    `json:"o"`
+	Value  string    `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+func newNotesQuery(authorID uuid.UUID, req ListNotesInput) (service.NotesQuery, error) {
+	sortBy := strings.ToLower(req.SortBy)
+	if sortBy == "" {
+		sortBy = SortByCreatedAt
+	}
+
+	if sortBy != SortByCreatedAt && sortBy != SortByUpdatedAt && sortBy != SortByTitle {
+		return service.NotesQuery{}, ErrInvalidSort
+	}
+
+	order := strings.ToLower(req.Order)
+	if order == "" {
+		order = OrderDesc
+	}
+
+	if order != OrderAsc && order != OrderDesc {
+		return service.NotesQuery{}, ErrInvalidOrder
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultNotesLimit
+	}
+
+	if limit < 1 || limit > MaxNotesLimit {
+		return service.NotesQuery{}, ErrInvalidLimit
+	}
+
+	query := service.NotesQuery{
+		AuthorID:    authorID,
+		Limit:       uint64(limit),
+		SortBy:      sortBy,
+		Desc:        order == OrderDesc,
+		Tags:        NormalizeTags(req.Tags),
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+	}
+
+	if req.Cursor != "" {
+		after, err := decodeNotesCursor(req.Cursor, sortBy, order)
+		if err != nil {
+			return service.NotesQuery{}, err
+		}
+
+		query.After = after
+	}
+
+	return query, nil
+}
+
+func encodeNotesCursor(query service.NotesQuery, last models.NoteOutput) string {
+	c := notesCursor{
+		SortBy: query.SortBy,
+		Order:  OrderAsc,
+		ID:     last.ID,
+	}
+
+	if query.Desc {
+		c.Order = OrderDesc
+	}
+
+	switch query.SortBy {
+	case SortByTitle:
+		c.Value = last.Title
+	case SortByUpdatedAt:
+		c.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeNotesCursor(token, sortBy, order string) (*service.NotesCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c notesCursor
+	if err = json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.SortBy != sortBy || c.Order != order || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if sortBy == SortByTitle {
+		return &service.NotesCursor{Value: c.Value, ID: c.ID}, nil
+	}
+
+	value, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &service.NotesCursor{Value: value, ID: c.ID}, nil
+}
@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	spaces     = regexp.MustCompile(`[ \t\r\n]+`)
+	// selfClosingENML matches ENML's own empty elements, such as <en-todo/>.
+	// The HTML parser ignores the slash and would nest what follows them.
+	selfClosingENML = regexp.MustCompile(`<(en-[a-z]+)([^<>]*?)/>`)
+)
+
+// enmlToMarkdown converts the ENML of an Evernote note, an XHTML dialect, to
+// Markdown. Formatting Markdown can't express is dropped, and so is embedded
+// media, which is not imported.
+func enmlToMarkdown(enml string) (string, error) {
+	enml = selfClosingENML.ReplaceAllString(enml, "<$1$2></$1>")
+
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", err
+	}
+
+	var c enmlConverter
+	c.children(doc)
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(c.b.String(), "\n\n")), nil
+}
+
+type enmlList struct {
+	ordered bool
+	n       int
+}
+
+type enmlConverter struct {
+	b     strings.Builder
+	lists []enmlList
+	pre   bool
+}
+
+func (c *enmlConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *enmlConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.Data {
+	case "head", "script", "style", "title", "en-media", "en-crypt", "img":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		level, _ := strconv.Atoi(n.Data[1:])
+		c.b.WriteString(strings.Repeat("#", level) + " ")
+		c.children(n)
+		c.block()
+	case "p", "div":
+		c.block()
+		c.children(n)
+		c.block()
+	case "br":
+		c.b.WriteString("\n")
+	case "hr":
+		c.block()
+		c.b.WriteString("---")
+		c.block()
+	case "b", "strong":
+		c.wrap(n, "**")
+	case "i", "em":
+		c.wrap(n, "_")
+	case "s", "strike", "del":
+		c.wrap(n, "~~")
+	case "code":
+		if c.pre {
+			c.children(n)
+		} else {
+			c.wrap(n, "`")
+		}
+	case "pre":
+		c.block()
+		c.b.WriteString("```\n")
+		c.pre = true
+		c.children(n)
+		c.pre = false
+		c.b.WriteString("\n```")
+		c.block()
+	case "a":
+		c.link(n)
+	case "ul", "ol":
+		if len(c.lists) == 0 {
+			c.block()
+		}
+		c.lists = append(c.lists, enmlList{ordered: n.Data == "ol"})
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		if len(c.lists) == 0 {
+			c.block()
+		}
+	case "li":
+		c.listItem(n)
+	case "en-todo":
+		if attr(n, "checked") == "true" {
+			c.b.WriteString("[x] ")
+		} else {
+			c.b.WriteString("[ ] ")
+		}
+	case "blockquote":
+		c.block()
+		c.b.WriteString(quote(c.convert(n)))
+		c.block()
+	case "table":
+		c.block()
+		c.table(n)
+		c.block()
+	default:
+		c.children(n)
+	}
+}
+
+func (c *enmlConverter) text(s string) {
+	if c.pre {
+		c.b.WriteString(s)
+		return
+	}
+
+	s = spaces.ReplaceAllString(s, " ")
+	if atLineStart(c.b.String()) {
+		s = strings.TrimLeft(s, " ")
+	}
+
+	c.b.WriteString(s)
+}
+
+// block ends the current block with a blank line.
+func (c *enmlConverter) block() {
+	out := c.b.String()
+	if out == "" || strings.HasSuffix(out, "\n\n") {
+		return
+	}
+
+	if strings.HasSuffix(out, "\n") {
+		c.b.WriteString("\n")
+	} else {
+		c.b.WriteString("\n\n")
+	}
+}
+
+func (c *enmlConverter) wrap(n *html.Node, marker string) {
+	c.b.WriteString(marker)
+	c.children(n)
+	c.b.WriteString(marker)
+}
+
+func (c *enmlConverter) link(n *html.Node) {
+	href := attr(n, "href")
+
+	lower := strings.ToLower(strings.TrimSpace(href))
+	if href == "" || !(strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "mailto:")) {
+		c.children(n)
+		return
+	}
+
+	c.b.WriteString("[")
+	c.children(n)
+	c.b.WriteString("](" + strings.ReplaceAll(href, ")", "%29") + ")")
+}
+
+func (c *enmlConverter) listItem(n *html.Node) {
+	if !atLineStart(c.b.String()) {
+		c.b.WriteString("\n")
+	}
+
+	depth := len(c.lists)
+	if depth == 0 {
+		c.children(n)
+		return
+	}
+
+	list := &c.lists[depth-1]
+	list.n++
+
+	c.b.WriteString(strings.Repeat("  ", depth-1))
+	if list.ordered {
+		c.b.WriteString(strconv.Itoa(list.n) + ". ")
+	} else {
+		c.b.WriteString("- ")
+	}
+
+	// Evernote wraps list item text in divs, which must not open a block.
+	item := c.convert(n)
+	item = strings.ReplaceAll(strings.TrimSpace(item), "\n\n", "\n")
+	c.b.WriteString(item)
+	c.b.WriteString("\n")
+}
+
+func (c *enmlConverter) table(n *html.Node) {
+	var rows [][]string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			switch child.Data {
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := spaces.ReplaceAllString(c.convert(cell), " ")
+						row = append(row, strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`))
+					}
+				}
+				rows = append(rows, row)
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		c.b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+
+		if i == 0 {
+			c.b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+}
+
+// convert renders the children of n on their own, to be placed by the
+// caller.
+func (c *enmlConverter) convert(n *html.Node) string {
+	sub := enmlConverter{lists: c.lists}
+	sub.children(n)
+
+	return sub.b.String()
+}
+
+func quote(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func atLineStart(s string) bool {
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
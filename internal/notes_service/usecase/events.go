@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/db/transactor"
+	"notes-rew/internal/notes_service/models"
+)
+
+// EventBus delivers note events to the users they concern.
+type EventBus interface {
+	Publish(ctx context.Context, event models.NoteEvent)
+	Subscribe(userID uuid.UUID) (<-chan models.NoteEvent, func())
+}
+
+// WatchNotes streams the events of the notes the current user can see,
+// until the returned function is called.
+func (u *NoteUsecase) WatchNotes(_ context.Context, currentUserID uuid.UUID) (<-chan models.NoteEvent, func()) {
+	return u.events.Subscribe(currentUserID)
+}
+
+// publish tells the author of the note, the users it is shared with and
+// extra users that it changed. Within a transaction the event is sent only
+// once it commits. Events are best effort: failing to find the recipients
+// doesn't fail the change itself.
+func (u *NoteUsecase) publish(
+	ctx context.Context,
+	eventType string,
+	noteID, authorID, actorID uuid.UUID,
+	version int64,
+	extra ...uuid.UUID,
+) {
+	shares, err := u.service.GetSharesByNoteID(ctx, noteID)
+	if err != nil {
+		logrus.Printf("error while getting recipients of %s event for note %s: %v", eventType, noteID, err)
+		return
+	}
+
+	// The author or a user still in the shares can be passed in extra as
+	// well; each recipient gets the event once.
+	candidates := make([]uuid.UUID, 0, len(shares)+len(extra)+1)
+	candidates = append(candidates, authorID)
+	for _, share := range shares {
+		candidates = append(candidates, share.UserID)
+	}
+	candidates = append(candidates, extra...)
+
+	seen := make(map[uuid.UUID]struct{}, len(candidates))
+	recipients := make([]uuid.UUID, 0, len(candidates))
+	for _, id := range candidates {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		recipients = append(recipients, id)
+	}
+
+	event := models.NoteEvent{
+		ID:         uuid.New(),
+		Type:       eventType,
+		NoteID:     noteID,
+		Version:    version,
+		ActorID:    actorID,
+		OccurredAt: time.Now().UTC(),
+		Recipients: recipients,
+	}
+
+	transactor.AfterCommit(ctx, func() {
+		u.events.Publish(ctx, event)
+	})
+}
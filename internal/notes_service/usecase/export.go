@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"notes-rew/internal/blobstore"
+	"notes-rew/internal/notes_service/models"
+)
+
+const maxExportNameLength = 100
+
+// frontMatter is the YAML header of an exported note. Attachments are paths
+// relative to the note's file.
+type frontMatter struct {
+	ID          uuid.UUID `yaml:"id"`
+	Title       string    `yaml:"title"`
+	Tags        []string  `yaml:"tags"`
+	CreatedAt   time.Time `yaml:"created_at"`
+	UpdatedAt   time.Time `yaml:"updated_at"`
+	Attachments []string  `yaml:"attachments,omitempty"`
+}
+
+// ExportNotes writes a zip archive of the user's notes to w. Every note is a
+// Markdown file with YAML front matter, placed in folders that follow its
+// notebooks. The files attached to a note go into a "<note>_files" folder
+// next to it. Notes are streamed one by one and never loaded all at once.
+func (u *NoteUsecase) ExportNotes(ctx context.Context, currentUserID uuid.UUID, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	names := make(map[string]bool)
+
+	err := u.service.ExportNotes(ctx, currentUserID, func(note models.ExportedNote) error {
+		return u.exportNote(ctx, archive, names, note)
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func (u *NoteUsecase) exportNote(
+	ctx context.Context,
+	archive *zip.Writer,
+	names map[string]bool,
+	note models.ExportedNote,
+) error {
+	dir := ""
+	for _, folder := range note.Folder {
+		dir = path.Join(dir, exportName(folder, "notebook"))
+	}
+
+	base := uniqueName(names, dir, exportName(note.Title, "untitled"), ".md")
+	filesDir := base + "_files"
+
+	fm := frontMatter{
+		ID:        note.ID,
+		Title:     note.Title,
+		Tags:      note.Tags,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+
+	attachmentNames := make(map[string]bool)
+	attachmentPaths := make([]string, 0, len(note.Attachments))
+	for _, attachment := range note.Attachments {
+		ext := path.Ext(attachment.Filename)
+		name := uniqueName(attachmentNames, "", exportName(strings.TrimSuffix(attachment.Filename, ext), "attachment"), ext)
+		attachmentPaths = append(attachmentPaths, name+ext)
+	}
+	for _, name := range attachmentPaths {
+		fm.Attachments = append(fm.Attachments, path.Base(filesDir)+"/"+name)
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return err
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("---\n")
+	doc.Write(header)
+	doc.WriteString("---\n\n")
+	doc.WriteString(note.Body)
+	if !strings.HasSuffix(note.Body, "\n") {
+		doc.WriteString("\n")
+	}
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     path.Join(dir, base+".md"),
+		Method:   zip.Deflate,
+		Modified: note.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = entry.Write(doc.Bytes()); err != nil {
+		return err
+	}
+
+	for i, attachment := range note.Attachments {
+		err = u.exportAttachment(ctx, archive, path.Join(dir, filesDir, attachmentPaths[i]), attachment, note.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportAttachment copies a blob into the archive. Images and PDFs hardly
+// compress, so they are stored as they are.
+func (u *NoteUsecase) exportAttachment(
+	ctx context.Context,
+	archive *zip.Writer,
+	name string,
+	attachment models.Attachment,
+	modified time.Time,
+) error {
+	content, err := u.blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		// A lost blob shouldn't cost the user the rest of their export.
+		if errors.Is(err, blobstore.ErrBlobNotFound) {
+			logrus.Printf("skipping missing blob %s of attachment %s", attachment.StorageKey, attachment.ID)
+			return nil
+		}
+		return err
+	}
+	defer content.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, content)
+
+	return err
+}
+
+// exportName turns a title into a file name that is safe on every common
+// file system.
+func exportName(title, fallback string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_', r == '.':
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return '_'
+		}
+	}, title)
+
+	name = strings.Trim(strings.Join(strings.Fields(name), " "), ". ")
+
+	if runes := []rune(name); len(runes) > maxExportNameLength {
+		name = strings.TrimSpace(string(runes[:maxExportNameLength]))
+	}
+
+	if name == "" {
+		return fallback
+	}
+
+	return name
+}
+
+// uniqueName returns base, or base with a number appended, such that
+// dir/name+ext hasn't been taken yet, and records it as taken. Names are
+// compared without case, for case-insensitive file systems.
+func uniqueName(taken map[string]bool, dir, base, ext string) string {
+	name := base
+	for i := 2; taken[strings.ToLower(path.Join(dir, name+ext))]; i++ {
+		name = fmt.Sprintf("%s (%d)", base, i)
+	}
+
+	taken[strings.ToLower(path.Join(dir, name+ext))] = true
+
+	return name
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const (
+	ImportFormatMarkdown = "markdown_zip"
+	ImportFormatENEX     = "enex"
+
+	// importProgressEvery is how many items are imported between two
+	// progress reports.
+	importProgressEvery = 25
+	// maxReportedFailures caps the failures listed in a job, the count
+	// stays exact.
+	maxReportedFailures = 100
+)
+
+var (
+	ErrImportTooLarge          = errors.New("import file is too large")
+	ErrUnsupportedImportFormat = errors.New("import file must be a zip of Markdown files or an Evernote .enex file")
+
+	errAlreadyImported = errors.New("item has been imported before")
+)
+
+// importItem is a note read from an import file. Key identifies the item
+// across imports. Err is set when the item couldn't be read.
+type importItem struct {
+	Name  string
+	Key   string
+	Title string
+	Body  string
+	Tags  []string
+	Err   error
+}
+
+// StartImport stores the uploaded file and queues a job to import it. The
+// job is run by RunNextImport; its progress is read with ReadImportJob.
+func (u *NoteUsecase) StartImport(ctx context.Context, currentUserID uuid.UUID, content io.Reader) (*models.ImportJob, error) {
+	if err := u.checkEmailVerified(ctx, currentUserID); err != nil {
+		return nil, err
+	}
+
+	format, content, err := detectImportFormat(content)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	job := service.CreateImportJob{
+		ID:        uuid.New(),
+		OwnerID:   currentUserID,
+		Format:    format,
+		CreatedAt: now,
+	}
+	job.SourceKey = "imports/" + job.ID.String()
+
+	size, err := u.blobs.Put(ctx, job.SourceKey, io.LimitReader(content, u.limits.MaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if size > u.limits.MaxImportSize {
+		u.deleteBlobs(ctx, job.SourceKey)
+		return nil, ErrImportTooLarge
+	}
+
+	if err = u.service.CreateImportJob(ctx, job); err != nil {
+		u.deleteBlobs(ctx, job.SourceKey)
+		return nil, err
+	}
+
+	return &models.ImportJob{
+		ID:        job.ID,
+		Format:    job.Format,
+		Status:    models.ImportPending,
+		Failures:  []models.ImportFailure{},
+		CreatedAt: now,
+		UpdatedAt: now,
+		OwnerID:   job.OwnerID,
+		SourceKey: job.SourceKey,
+	}, nil
+}
+
+// detectImportFormat tells the format from the first bytes of the content
+// and returns a reader that still yields all of it.
+func detectImportFormat(content io.Reader) (string, io.Reader, error) {
+	head := make([]byte, 512)
+
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+
+	content = io.MultiReader(strings.NewReader(string(head)), content)
+
+	switch contentType := http.DetectContentType(head); {
+	case contentType == "application/zip":
+		return ImportFormatMarkdown, content, nil
+	case strings.HasPrefix(contentType, "text/xml") && strings.Contains(string(head), "<en-export"):
+		return ImportFormatENEX, content, nil
+	default:
+		return "", nil, ErrUnsupportedImportFormat
+	}
+}
+
+// ReadImportJob returns a job of the current user.
+func (u *NoteUsecase) ReadImportJob(ctx context.Context, jobID, currentUserID uuid.UUID) (*models.ImportJob, error) {
+	job, err := u.service.GetImportJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else's job doesn't exist as far as the user is concerned.
+	if job.OwnerID != currentUserID {
+		return nil, models.ErrImportJobNotFound
+	}
+
+	return &job, nil
+}
+
+// RunNextImport claims the next queued import job and runs it to the end.
+// It reports false when there was no job to run. Jobs whose worker stopped
+// for longer than staleAfter are run again; the items they imported already
+// are skipped.
+func (u *NoteUsecase) RunNextImport(ctx context.Context, staleAfter time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	job, err := u.service.ClaimImportJob(ctx, now.Add(-staleAfter), now)
+	if err != nil {
+		if errors.Is(err, models.ErrImportJobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	progress := service.UpdateImportJob{
+		ID:     job.ID,
+		Status: models.ImportRunning,
+	}
+
+	err = u.runImport(ctx, job, &progress)
+	if err != nil && ctx.Err() != nil {
+		// Stopped midway: the job stays running and is taken over once
+		// it goes stale.
+		return true, err
+	}
+
+	finishedAt := time.Now().UTC()
+	progress.Status = models.ImportDone
+	progress.UpdatedAt = finishedAt
+	progress.FinishedAt = &finishedAt
+
+	if err != nil {
+		logrus.Printf("import job %s failed: %v", job.ID, err)
+
+		reason := importErrorReason(err)
+		progress.Status = models.ImportFailed
+		progress.Error = &reason
+	}
+
+	if err = u.service.UpdateImportJob(ctx, progress); err != nil {
+		return true, err
+	}
+
+	u.deleteBlobs(ctx, job.SourceKey)
+
+	return true, nil
+}
+
+func (u *NoteUsecase) runImport(ctx context.Context, job models.ImportJob, progress *service.UpdateImportJob) error {
+	content, err := u.blobs.Open(ctx, job.SourceKey)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	each := func(item importItem) error {
+		u.importItem(ctx, job, item, progress)
+
+		if (progress.Created+progress.Skipped+progress.Failed)%importProgressEvery == 0 {
+			progress.UpdatedAt = time.Now().UTC()
+			if err := u.service.UpdateImportJob(ctx, *progress); err != nil {
+				return err
+			}
+		}
+
+		return ctx.Err()
+	}
+
+	switch job.Format {
+	case ImportFormatMarkdown:
+		return readMarkdownZip(content, each)
+	case ImportFormatENEX:
+		return readENEX(content, each)
+	default:
+		return ErrUnsupportedImportFormat
+	}
+}
+
+// importItem creates the note of an item through CreateNote, unless the
+// owner imported the item before. The note and the record of its import are
+// saved together, so concurrent imports of one item create one note.
+func (u *NoteUsecase) importItem(ctx context.Context, job models.ImportJob, item importItem, progress *service.UpdateImportJob) {
+	err := item.Err
+	if err == nil {
+		err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			noteID, err := u.CreateNote(ctx, CreateNoteInput{
+				Title:  item.Title,
+				Body:   item.Body,
+				Tags:   item.Tags,
+				Author: job.OwnerID,
+			})
+			if err != nil {
+				return err
+			}
+
+			imported, err := u.service.SaveImportedNote(ctx, service.CreateImportedNote{
+				OwnerID: job.OwnerID,
+				ItemKey: item.Key,
+				NoteID:  noteID,
+				JobID:   job.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if !imported {
+				return errAlreadyImported
+			}
+
+			return nil
+		})
+	}
+
+	switch {
+	case err == nil:
+		progress.Created++
+	case errors.Is(err, errAlreadyImported):
+		progress.Skipped++
+	default:
+		progress.Failed++
+		if len(progress.Failures) < maxReportedFailures {
+			progress.Failures = append(progress.Failures, models.ImportFailure{
+				Item:   item.Name,
+				Reason: importErrorReason(err),
+			})
+		}
+	}
+}
+
+// importError is a failure worth showing to the user as it is.
+type importError struct {
+	reason string
+}
+
+func (e *importError) Error() string {
+	return e.reason
+}
+
+func importErrorReason(err error) string {
+	var ie *importError
+	if errors.As(err, &ie) {
+		return ie.reason
+	}
+
+	return "internal error"
+}
+
+// importItemKey identifies an item by where it comes from and what it says,
+// so that importing the same file twice matches every item.
+func importItemKey(format, source, title, body string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{source, title, body}, "\x00")))
+	return format + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxImportedBodySize matches the limit on note bodies.
+	maxImportedBodySize = 30000000
+	maxTitleLength      = 50
+	untitled            = "Untitled"
+)
+
+var (
+	errBodyTooLarge = &importError{reason: "note body is too large"}
+	errNotUTF8      = &importError{reason: "file is not valid UTF-8 text"}
+)
+
+// readMarkdownZip calls each with every Markdown file of a zip archive, in
+// archive order. Other files are ignored.
+func readMarkdownZip(content io.ReadSeeker, each func(item importItem) error) error {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	archive, err := zip.NewReader(&seekReaderAt{r: content}, size)
+	if err != nil {
+		return &importError{reason: "file is not a valid zip archive"}
+	}
+
+	for _, file := range archive.File {
+		if !isMarkdownFile(file) {
+			continue
+		}
+
+		if err = each(readMarkdownFile(file)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isMarkdownFile(file *zip.File) bool {
+	if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") ||
+		strings.HasPrefix(path.Base(file.Name), ".") {
+		return false
+	}
+
+	switch strings.ToLower(path.Ext(file.Name)) {
+	case ".md", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+// markdownFrontMatter holds the front matter fields an import uses. The rest,
+// such as the ids and dates of an export, is ignored.
+type markdownFrontMatter struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags"`
+}
+
+func readMarkdownFile(file *zip.File) importItem {
+	item := importItem{Name: file.Name}
+
+	if file.UncompressedSize64 > maxImportedBodySize*2 {
+		item.Err = errBodyTooLarge
+		return item
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		item.Err = &importError{reason: "file can't be read from the archive"}
+		return item
+	}
+	defer rc.Close()
+
+	// The size in the header can lie, the reader can't.
+	raw, err := io.ReadAll(io.LimitReader(rc, maxImportedBodySize*2+1))
+	if err != nil {
+		item.Err = &importError{reason: "file can't be read from the archive"}
+		return item
+	}
+
+	if !utf8.Valid(raw) {
+		item.Err = errNotUTF8
+		return item
+	}
+
+	fm, body, err := splitFrontMatter(string(raw))
+	if err != nil {
+		item.Err = err
+		return item
+	}
+
+	title := fm.Title
+	if title == "" {
+		title = strings.TrimSuffix(path.Base(file.Name), path.Ext(file.Name))
+	}
+
+	if len(body) > maxImportedBodySize {
+		item.Err = errBodyTooLarge
+		return item
+	}
+
+	item.Title = noteTitle(title)
+	item.Body = body
+	item.Tags = fm.Tags
+	item.Key = importItemKey(ImportFormatMarkdown, file.Name, item.Title, item.Body)
+
+	return item
+}
+
+// splitFrontMatter separates the YAML front matter, enclosed in "---" lines
+// at the very start, from the Markdown that follows it.
+func splitFrontMatter(source string) (markdownFrontMatter, string, error) {
+	var fm markdownFrontMatter
+
+	source = strings.TrimPrefix(source, "\ufeff")
+
+	rest, ok := cutLine(source, "---")
+	if !ok {
+		return fm, source, nil
+	}
+
+	end := -1
+	for offset := 0; offset < len(rest); {
+		line := rest[offset:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+
+		if strings.TrimRight(line, "\r") == "---" {
+			end = offset
+			break
+		}
+
+		offset += len(line) + 1
+	}
+
+	if end < 0 {
+		return fm, source, nil
+	}
+
+	if err := yaml.Unmarshal([]byte(rest[:end]), &fm); err != nil {
+		return fm, "", &importError{reason: "front matter is not valid YAML"}
+	}
+
+	body, _ := cutLine(rest[end:], "---")
+
+	return fm, strings.TrimLeft(body, "\r\n"), nil
+}
+
+// cutLine removes the first line of s if it is line, reporting whether it
+// did.
+func cutLine(s, line string) (string, bool) {
+	first, rest, found := strings.Cut(s, "\n")
+	if strings.TrimRight(first, "\r") != line {
+		return s, false
+	}
+
+	if !found {
+		return "", true
+	}
+
+	return rest, true
+}
+
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Created string   `xml:"created"`
+	Tags    []string `xml:"tag"`
+}
+
+// readENEX calls each with every note of an Evernote export, decoding one
+// note at a time.
+func readENEX(content io.Reader, each func(item importItem) error) error {
+	decoder := xml.NewDecoder(content)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	for i := 1; ; {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return &importError{reason: "file is not a valid Evernote export"}
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var note enexNote
+		if err = decoder.DecodeElement(&note, &start); err != nil {
+			return &importError{reason: "file is not a valid Evernote export"}
+		}
+
+		if err = each(enexItem(i, note)); err != nil {
+			return err
+		}
+		i++
+	}
+}
+
+func enexItem(i int, note enexNote) importItem {
+	item := importItem{Name: note.Title}
+	if item.Name == "" {
+		item.Name = "note " + strconv.Itoa(i)
+	}
+
+	body, err := enmlToMarkdown(note.Content)
+	if err != nil {
+		item.Err = &importError{reason: "note content is not valid ENML"}
+		return item
+	}
+
+	if len(body) > maxImportedBodySize {
+		item.Err = errBodyTooLarge
+		return item
+	}
+
+	item.Title = noteTitle(note.Title)
+	item.Body = body
+	item.Tags = note.Tags
+	item.Key = importItemKey(ImportFormatENEX, note.Created, item.Title, item.Body)
+
+	return item
+}
+
+// noteTitle fits a title to the rules for note titles: up to 50 ASCII
+// letters and digits.
+func noteTitle(title string) string {
+	var b strings.Builder
+
+	for _, r := range title {
+		if b.Len() == maxTitleLength {
+			break
+		}
+
+		if r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() == 0 {
+		return untitled
+	}
+
+	return b.String()
+}
+
+// seekReaderAt gives zip the io.ReaderAt it needs over a blob, which only
+// seeks.
+type seekReaderAt struct {
+	mu sync.Mutex
+	r  io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(s.r, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+const linkTokenBytes = 32
+
+var (
+	ErrInvalidExpiry       = errors.New("expiry must be in the future")
+	ErrLinkExpired         = errors.New("link has expired")
+	ErrInvalidLinkPassword = errors.New("link password is missing or invalid")
+)
+
+// CreateLink mints a public read-only link for a note. The returned token is
+// never stored and can't be recovered later.
+func (u *NoteUsecase) CreateLink(
+	ctx context.Context,
+	noteID, currentUserID uuid.UUID,
+	req CreateLinkInput,
+) (*models.CreatedNoteLink, error) {
+	now := time.Now().UTC()
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, ErrInvalidExpiry
+	}
+
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return nil, err
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := service.CreateLink{
+		ID:        uuid.New(),
+		NoteID:    noteID,
+		TokenHash: hashLinkToken(token),
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	if req.Password != "" {
+		passwordHash, err := u.hasher.HasherPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = &passwordHash
+	}
+
+	if err = u.service.SaveLink(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedNoteLink{
+		NoteLink: models.NoteLink{
+			ID:          link.ID,
+			NoteID:      link.NoteID,
+			HasPassword: link.PasswordHash != nil,
+			ExpiresAt:   link.ExpiresAt,
+			CreatedAt:   link.CreatedAt,
+		},
+		Token: token,
+	}, nil
+}
+
+func (u *NoteUsecase) ReadLinks(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.NoteLink, error) {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return nil, err
+	}
+
+	return u.service.GetLinksByNoteID(ctx, noteID)
+}
+
+func (u *NoteUsecase) RevokeLink(ctx context.Context, noteID, linkID, currentUserID uuid.UUID) error {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return err
+	}
+
+	return u.service.DeleteLink(ctx, noteID, linkID)
+}
+
+// ReadPublicNote resolves a link token to the note it points to. Trashed notes
+// are treated as missing, same as for authenticated reads.
+func (u *NoteUsecase) ReadPublicNote(ctx context.Context, token, password string) (*models.PublicNote, error) {
+	link, err := u.service.GetLinkByTokenHash(ctx, hashLinkToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now().UTC()) {
+		return nil, ErrLinkExpired
+	}
+
+	if link.HasPassword {
+		if password == "" {
+			return nil, ErrInvalidLinkPassword
+		}
+		if err = u.hasher.ComparePassword(link.PasswordHash, password); err != nil {
+			return nil, ErrInvalidLinkPassword
+		}
+	}
+
+	note, err := u.service.GetNoteByID(ctx, link.NoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PublicNote{
+		Title:     note.Title,
+		Body:      note.Body,
+		Tags:      note.Tags,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}, nil
+}
+
+func newLinkToken() (string, error) {
+	b := make([]byte, linkTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashLinkToken hashes tokens with SHA-256 rather than the password hasher:
+// tokens carry enough entropy on their own and must be looked up by hash.
+func hashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
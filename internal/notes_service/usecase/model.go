@@ -1,16 +1,21 @@
 package usecase
 
 import (
+	"io"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type CreateNoteInput struct {
-	Title  string   `json:"title" validate:"required,alphanum,min=1,max=50"`
-	Body   string   `json:"body" validate:"required,bytesize"`
-	Tags   []string `json:"tags" validate:"omitempty"`
-	Author uuid.UUID
+	// ID is chosen by clients that create notes offline; a new one is
+	// generated when it is zero.
+	ID         uuid.UUID
+	Title      string   `json:"title" validate:"required,alphanum,min=1,max=50"`
+	Body       string   `json:"body" validate:"required,bytesize"`
+	Tags       []string `json:"tags" validate:"omitempty"`
+	Author     uuid.UUID
+	NotebookID *uuid.UUID
 }
 
 type UpdateNoteInput struct {
@@ -18,6 +23,9 @@ type UpdateNoteInput struct {
 	Body      *string   `json:"body" validate:"required,bytesize"`
 	Tags      *[]string `json:"tags" validate:"omitempty"`
 	UpdatedAt time.Time
+	// ExpectedVersion, when set, makes the update fail with a
+	// VersionConflictError unless the note is still at this version.
+	ExpectedVersion *int64
 }
 
 func NewUpdateNoteInput(title *string, body *string, tags *[]string) (UpdateNoteInput, error) {
@@ -28,3 +36,65 @@ func NewUpdateNoteInput(title *string, body *string, tags *[]string) (UpdateNote
 		UpdatedAt: time.Now().UTC(),
 	}, nil
 }
+
+type ListNotesInput struct {
+	Limit       int
+	Cursor      string
+	SortBy      string
+	Order       string
+	Tags        []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+type SearchNotesInput struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type ShareNoteInput struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+type CreateLinkInput struct {
+	ExpiresAt *time.Time
+	Password  string
+}
+
+type MergeTagsInput struct {
+	Sources []string
+	Target  string
+}
+
+// UploadLimits bound, in bytes, the size of one attachment, the total size
+// of the attachments of one user and the size of an import file.
+type UploadLimits struct {
+	MaxAttachmentSize int64
+	AttachmentQuota   int64
+	MaxImportSize     int64
+}
+
+type AddAttachmentInput struct {
+	Filename string
+	Content  io.Reader
+}
+
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncChangeInput is one change a client made offline. Updates and deletes
+// apply only if the note is still at BaseVersion.
+type SyncChangeInput struct {
+	Op          string
+	ID          uuid.UUID
+	Title       *string
+	Body        *string
+	Tags        *[]string
+	NotebookID  *uuid.UUID
+	BaseVersion int64
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+// MoveNote puts a note into one of the author's notebooks, or takes it out of
+// any notebook when notebookID is nil. Only the author may move a note.
+func (u *NoteUsecase) MoveNote(ctx context.Context, noteID, currentUserID uuid.UUID, notebookID *uuid.UUID) error {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return err
+	}
+
+	if notebookID != nil {
+		if err := u.checkNotebookAuthor(ctx, *notebookID, currentUserID); err != nil {
+			return err
+		}
+	}
+
+	if err := u.service.MoveNoteByID(ctx, noteID, currentUserID, notebookID, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	u.publish(ctx, models.NoteUpdated, noteID, currentUserID, currentUserID, 0)
+
+	return nil
+}
+
+// checkNotebookAuthor reports other users' notebooks as missing so that their
+// IDs can't be probed.
+func (u *NoteUsecase) checkNotebookAuthor(ctx context.Context, notebookID, currentUserID uuid.UUID) error {
+	author, err := u.service.GetNotebookAuthorID(ctx, notebookID)
+	if err != nil {
+		return err
+	}
+
+	if author != currentUserID {
+		return models.ErrNotebookNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/markdown"
+	"notes-rew/internal/notes_service/models"
+)
+
+// RenderNote returns the body of the note rendered from Markdown to
+// sanitized HTML, with a table of contents built from its headings.
+func (u *NoteUsecase) RenderNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.RenderedNote, error) {
+	note, err := u.authorize(ctx, noteID, currentUserID, accessRead)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := u.service.GetRenderedNote(ctx, note.ID, note.Version, func(ctx context.Context) (models.RenderedNote, error) {
+		return renderNote(note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rendered, nil
+}
+
+func renderNote(note *models.NoteOutput) (models.RenderedNote, error) {
+	doc, err := markdown.Render(note.Body)
+	if err != nil {
+		return models.RenderedNote{}, err
+	}
+
+	toc := make([]models.Heading, 0, len(doc.TOC))
+	for _, heading := range doc.TOC {
+		toc = append(toc, models.Heading(heading))
+	}
+
+	return models.RenderedNote{
+		ID:      note.ID,
+		Title:   note.Title,
+		Version: note.Version,
+		HTML:    doc.HTML,
+		TOC:     toc,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/diff"
+	"notes-rew/internal/notes_service/models"
+)
+
+// CurrentRevision addresses the current version of a note in DiffRevisions.
+const CurrentRevision = 0
+
+var ErrInvalidRevision = errors.New("revision must be a positive number")
+
+func (u *NoteUsecase) ReadRevisions(
+	ctx context.Context,
+	noteID, currentUserID uuid.UUID,
+) ([]models.NoteRevision, error) {
+	if _, err := u.ReadNote(ctx, noteID, currentUserID); err != nil {
+		return nil, err
+	}
+
+	return u.service.GetRevisionsByNoteID(ctx, noteID)
+}
+
+func (u *NoteUsecase) ReadRevision(
+	ctx context.Context,
+	noteID uuid.UUID,
+	number int,
+	currentUserID uuid.UUID,
+) (*models.NoteRevision, error) {
+	if number <= 0 {
+		return nil, ErrInvalidRevision
+	}
+
+	if _, err := u.ReadNote(ctx, noteID, currentUserID); err != nil {
+		return nil, err
+	}
+
+	revision, err := u.service.GetRevision(ctx, noteID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+func (u *NoteUsecase) DiffRevisions(
+	ctx context.Context,
+	noteID uuid.UUID,
+	from, to int,
+	currentUserID uuid.UUID,
+) (*models.RevisionDiff, error) {
+	if from < 0 || to < 0 {
+		return nil, ErrInvalidRevision
+	}
+
+	note, err := u.ReadNote(ctx, noteID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := models.NoteRevision{
+		NoteID: note.ID,
+		Title:  note.Title,
+		Body:   note.Body,
+		Tags:   note.Tags,
+	}
+
+	left, right := current, current
+
+	if from != CurrentRevision {
+		if left, err = u.service.GetRevision(ctx, noteID, from); err != nil {
+			return nil, err
+		}
+	}
+
+	if to != CurrentRevision {
+		if right, err = u.service.GetRevision(ctx, noteID, to); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.RevisionDiff{
+		NoteID:    noteID,
+		From:      from,
+		To:        to,
+		TitleFrom: left.Title,
+		TitleTo:   right.Title,
+		TagsFrom:  left.Tags,
+		TagsTo:    right.Tags,
+		Lines:     diff.Lines(left.Body, right.Body),
+	}, nil
+}
+
+// RestoreRevision makes an old revision the current version of the note.
+// The version being replaced is archived like on any other update, so a
+// restore can itself be undone.
+func (u *NoteUsecase) RestoreRevision(
+	ctx context.Context,
+	noteID uuid.UUID,
+	number int,
+	currentUserID uuid.UUID,
+) error {
+	revision, err := u.ReadRevision(ctx, noteID, number, currentUserID)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.UpdateNote(ctx, noteID, currentUserID, UpdateNoteInput{
+		Title: &revision.Title,
+		Body:  &revision.Body,
+		Tags:  &revision.Tags,
+	})
+
+	return err
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+	"notes-rew/internal/notes_service/service"
+)
+
+type access int
+
+const (
+	accessRead access = iota
+	accessWrite
+	accessOwner
+)
+
+var (
+	ErrInvalidRole  = errors.New("role must be viewer or editor")
+	ErrShareToOwner = errors.New("a note can't be shared with its author")
+)
+
+// authorize loads the note and checks that the user may access it at the
+// requested level: authors may do anything, editors may read and write,
+// viewers may only read.
+func (u *NoteUsecase) authorize(
+	ctx context.Context,
+	noteID, currentUserID uuid.UUID,
+	need access,
+) (*models.NoteOutput, error) {
+	note, err := u.service.GetNoteByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Author == currentUserID {
+		return note, nil
+	}
+
+	if need == accessOwner {
+		return nil, models.ErrForbidden
+	}
+
+	role, err := u.service.GetShareRole(ctx, noteID, currentUserID)
+	if err != nil {
+		if errors.Is(err, models.ErrShareNotFound) {
+			return nil, fmt.Errorf("user is not author of this note")
+		}
+		return nil, err
+	}
+
+	if need == accessWrite && role != models.RoleEditor {
+		return nil, models.ErrForbidden
+	}
+
+	return note, nil
+}
+
+func (u *NoteUsecase) ShareNote(ctx context.Context, noteID, currentUserID uuid.UUID, req ShareNoteInput) error {
+	if req.Role != models.RoleViewer && req.Role != models.RoleEditor {
+		return ErrInvalidRole
+	}
+
+	if req.UserID == currentUserID {
+		return ErrShareToOwner
+	}
+
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return err
+	}
+
+	err := u.service.SaveShare(ctx, service.CreateShare{
+		NoteID:    noteID,
+		UserID:    req.UserID,
+		Role:      req.Role,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	u.publish(ctx, models.NoteShared, noteID, currentUserID, currentUserID, 0)
+
+	return nil
+}
+
+func (u *NoteUsecase) ReadShares(ctx context.Context, noteID, currentUserID uuid.UUID) ([]models.NoteShare, error) {
+	if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+		return nil, err
+	}
+
+	return u.service.GetSharesByNoteID(ctx, noteID)
+}
+
+// RevokeShare removes a user's access to a note. The author may revoke any
+// share, and a user may always give up a share they received.
+func (u *NoteUsecase) RevokeShare(ctx context.Context, noteID, userID, currentUserID uuid.UUID) error {
+	if userID != currentUserID {
+		if _, err := u.authorize(ctx, noteID, currentUserID, accessOwner); err != nil {
+			return err
+		}
+	}
+
+	if err := u.service.DeleteShare(ctx, noteID, userID); err != nil {
+		return err
+	}
+
+	note, err := u.service.GetNoteByID(ctx, noteID)
+	if err != nil {
+		logrus.Printf("error while getting note %s for unshared event: %v", noteID, err)
+		return nil
+	}
+
+	// The revoked user is no longer among the shares but still has to
+	// learn that the note is gone from their list.
+	u.publish(ctx, models.NoteUnshared, noteID, note.Author, currentUserID, 0, userID)
+
+	return nil
+}
+
+func (u *NoteUsecase) ReadSharedNotes(ctx context.Context, currentUserID uuid.UUID) ([]models.SharedNote, error) {
+	return u.service.GetNotesSharedWithUser(ctx, currentUserID)
+}
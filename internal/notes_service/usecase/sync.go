@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"notes-rew/internal/notes_service/models"
+)
+
+const (
+	DefaultSyncLimit = 200
+	MaxSyncLimit     = 1000
+	MaxSyncChanges   = 100
+)
+
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	ErrInvalidSyncLimit = errors.New("limit must be between 1 and 1000")
+	ErrTooManyChanges   = errors.New("at most 100 changes can be pushed at once")
+	ErrNoChanges        = errors.New("no changes to push")
+	errMissingVersion   = errors.New("base_version is required")
+	errMissingContent   = errors.New("title and body are required")
+)
+
+// syncToken is serialized into the opaque sync_token. It holds the number
+// of the last change the client has seen.
+type syncToken struct {
+	Seq int64 `json:"s"`
+}
+
+// PullChanges returns the user's notes that changed since the sync token
+// and tombstones for those moved to the trash or purged. Without a token
+// it returns all notes outside of the trash, for a first sync.
+func (u *NoteUsecase) PullChanges(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	token string,
+	limit int,
+) (*models.SyncPage, error) {
+	if limit == 0 {
+		limit = DefaultSyncLimit
+	}
+
+	if limit < 1 || limit > MaxSyncLimit {
+		return nil, ErrInvalidSyncLimit
+	}
+
+	var since int64
+	if token != "" {
+		var err error
+		if since, err = decodeSyncToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra change tells us whether there are more.
+	changes, err := u.service.GetNoteChanges(ctx, currentUserID, since, uint64(limit)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.SyncPage{
+		Notes:      []models.NoteOutput{},
+		Tombstones: []models.Tombstone{},
+		SyncToken:  encodeSyncToken(since),
+	}
+
+	if len(changes) > limit {
+		changes = changes[:limit]
+		page.HasMore = true
+	}
+
+	// A purged note can be created again with the same ID, so the page may
+	// hold both its tombstone and the new note. Clients apply notes and
+	// tombstones separately, so only the latest change of each note is kept.
+	latest := make(map[uuid.UUID]int64, len(changes))
+	for _, change := range changes {
+		latest[change.Note.ID] = change.Seq
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Seq < latest[change.Note.ID]:
+			continue
+		case change.DeletedAt == nil:
+			page.Notes = append(page.Notes, change.Note)
+		case token != "":
+			// A first sync has nothing to delete.
+			page.Tombstones = append(page.Tombstones, models.Tombstone{
+				ID:        change.Note.ID,
+				DeletedAt: *change.DeletedAt,
+			})
+		}
+	}
+
+	if len(changes) > 0 {
+		page.SyncToken = encodeSyncToken(changes[len(changes)-1].Seq)
+	}
+
+	return page, nil
+}
+
+// PushChanges applies the changes a client made offline, in order and each
+// on its own: one that conflicts or fails doesn't stop the others. Only
+// the user's own notes can be changed, as only those are synced.
+func (u *NoteUsecase) PushChanges(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	changes []SyncChangeInput,
+) ([]models.SyncResult, error) {
+	if len(changes) == 0 {
+		return nil, ErrNoChanges
+	}
+
+	if len(changes) > MaxSyncChanges {
+		return nil, ErrTooManyChanges
+	}
+
+	results := make([]models.SyncResult, 0, len(changes))
+	for _, change := range changes {
+		results = append(results, u.applyChange(ctx, currentUserID, change))
+	}
+
+	return results, nil
+}
+
+func (u *NoteUsecase) applyChange(ctx context.Context, currentUserID uuid.UUID, change SyncChangeInput) models.SyncResult {
+	result := models.SyncResult{ID: change.ID}
+
+	var err error
+
+	switch change.Op {
+	case SyncCreate:
+		err = u.syncCreate(ctx, currentUserID, change, &result)
+	case SyncUpdate:
+		err = u.syncUpdate(ctx, currentUserID, change, &result)
+	case SyncDelete:
+		err = u.syncDelete(ctx, currentUserID, change)
+	default:
+		result.Status = models.SyncRejected
+		result.Error = "op must be one of create, update, delete"
+		return result
+	}
+
+	var conflict *models.VersionConflictError
+
+	switch {
+	case err == nil:
+		result.Status = models.SyncApplied
+	case errors.As(err, &conflict):
+		result.Status = models.SyncConflict
+		result.CurrentVersion = conflict.CurrentVersion
+	case errors.Is(err, models.ErrNoteNotFound):
+		result.Status = models.SyncNotFound
+	case errors.Is(err, models.ErrNotebookNotFound),
+		errors.Is(err, models.ErrEmailNotVerified),
+		errors.Is(err, errMissingVersion),
+		errors.Is(err, errMissingContent):
+		result.Status = models.SyncRejected
+		result.Error = err.Error()
+	default:
+		logrus.Printf("error while applying synced %s of note %s: %v", change.Op, change.ID, err)
+		result.Status = models.SyncFailed
+		result.Error = "change could not be applied, retry later"
+	}
+
+	return result
+}
+
+func (u *NoteUsecase) syncCreate(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	change SyncChangeInput,
+	result *models.SyncResult,
+) error {
+	if change.Title == nil || change.Body == nil {
+		return errMissingContent
+	}
+
+	req := CreateNoteInput{
+		ID:         change.ID,
+		Title:      *change.Title,
+		Body:       *change.Body,
+		Author:     currentUserID,
+		NotebookID: change.NotebookID,
+	}
+	if change.Tags != nil {
+		req.Tags = *change.Tags
+	}
+
+	_, err := u.CreateNote(ctx, req)
+	if errors.Is(err, models.ErrNoteExists) {
+		// A retried create finds the note it made before. Another user's
+		// note with the same ID is not revealed.
+		note, getErr := u.service.GetNoteByID(ctx, change.ID)
+		if getErr != nil || note.Author != currentUserID {
+			return err
+		}
+		return &models.VersionConflictError{CurrentVersion: note.Version}
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Version = 1
+
+	return nil
+}
+
+func (u *NoteUsecase) syncUpdate(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	change SyncChangeInput,
+	result *models.SyncResult,
+) error {
+	if change.BaseVersion < 1 {
+		return errMissingVersion
+	}
+
+	if err := u.checkOwnNote(ctx, change.ID, currentUserID); err != nil {
+		return err
+	}
+
+	version, err := u.UpdateNote(ctx, change.ID, currentUserID, UpdateNoteInput{
+		Title:           change.Title,
+		Body:            change.Body,
+		Tags:            change.Tags,
+		ExpectedVersion: &change.BaseVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Version = version
+
+	return nil
+}
+
+func (u *NoteUsecase) syncDelete(ctx context.Context, currentUserID uuid.UUID, change SyncChangeInput) error {
+	if change.BaseVersion < 1 {
+		return errMissingVersion
+	}
+
+	if err := u.checkOwnNote(ctx, change.ID, currentUserID); err != nil {
+		return err
+	}
+
+	// A note changed elsewhere since the client saw it is kept, so that the
+	// change isn't thrown away unseen. The delete checks the version itself,
+	// so an update can't slip in between.
+	return u.deleteNote(ctx, change.ID, currentUserID, &change.BaseVersion)
+}
+
+// checkOwnNote reports the notes of other users as missing, shared or not.
+func (u *NoteUsecase) checkOwnNote(ctx context.Context, noteID, currentUserID uuid.UUID) error {
+	note, err := u.service.GetNoteByID(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	if note.Author != currentUserID {
+		return models.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+func encodeSyncToken(seq int64) string {
+	raw, _ := json.Marshal(syncToken{Seq: seq})
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSyncToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidSyncToken
+	}
+
+	var t syncToken
+	if err = json.Unmarshal(raw, &t); err != nil || t.Seq < 0 {
+		return 0, ErrInvalidSyncToken
+	}
+
+	return t.Seq, nil
+}
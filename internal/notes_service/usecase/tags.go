@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+var (
+	ErrEmptyTag       = errors.New("tag must not be empty")
+	ErrNoSourceTags   = errors.New("at least one source tag is required")
+	ErrSameTagRenamed = errors.New("new tag name is the same as the old one")
+)
+
+// NormalizeTag lower-cases a tag and collapses runs of whitespace into single
+// spaces, so that "Go  Lang " and "go lang" end up as the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags normalizes every tag, dropping empty tags and duplicates while
+// keeping the original order.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+func (u *NoteUsecase) ReadTags(ctx context.Context, currentUserID uuid.UUID) ([]models.TagCount, error) {
+	return u.service.GetTagCounts(ctx, currentUserID)
+}
+
+// RenameTag renames a tag on all of the current user's notes. It returns the
+// number of notes that changed.
+func (u *NoteUsecase) RenameTag(ctx context.Context, currentUserID uuid.UUID, name, newName string) (int64, error) {
+	name, newName = NormalizeTag(name), NormalizeTag(newName)
+
+	if name == "" || newName == "" {
+		return 0, ErrEmptyTag
+	}
+
+	if name == newName {
+		return 0, ErrSameTagRenamed
+	}
+
+	return u.service.ReplaceTags(ctx, currentUserID, []string{name}, newName, time.Now().UTC())
+}
+
+// MergeTags replaces several tags with a single one on all of the current
+// user's notes. It returns the number of notes that changed.
+func (u *NoteUsecase) MergeTags(ctx context.Context, currentUserID uuid.UUID, req MergeTagsInput) (int64, error) {
+	target := NormalizeTag(req.Target)
+	if target == "" {
+		return 0, ErrEmptyTag
+	}
+
+	sources := make([]string, 0, len(req.Sources))
+	for _, source := range NormalizeTags(req.Sources) {
+		if source != target {
+			sources = append(sources, source)
+		}
+	}
+
+	if len(sources) == 0 {
+		return 0, ErrNoSourceTags
+	}
+
+	return u.service.ReplaceTags(ctx, currentUserID, sources, target, time.Now().UTC())
+}
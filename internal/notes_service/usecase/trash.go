@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"notes-rew/internal/notes_service/models"
+)
+
+func (u *NoteUsecase) ReadTrash(ctx context.Context, currentUserID uuid.UUID) ([]models.TrashedNote, error) {
+	return u.service.GetTrashedNotesByAuthorID(ctx, currentUserID)
+}
+
+func (u *NoteUsecase) RestoreNote(ctx context.Context, id, currentUserID uuid.UUID) error {
+	if err := u.checkTrashedNoteAuthor(ctx, id, currentUserID); err != nil {
+		return err
+	}
+
+	if err := u.service.RestoreNoteByID(ctx, id, currentUserID); err != nil {
+		return err
+	}
+
+	u.publish(ctx, models.NoteUpdated, id, currentUserID, currentUserID, 0)
+
+	return nil
+}
+
+// PurgeNote permanently deletes a note from the trash, along with the files
+// attached to it.
+func (u *NoteUsecase) PurgeNote(ctx context.Context, id, currentUserID uuid.UUID) error {
+	if err := u.checkTrashedNoteAuthor(ctx, id, currentUserID); err != nil {
+		return err
+	}
+
+	var keys []string
+
+	// The note stays locked between collecting its blob keys and purging
+	// it, so it can't be restored in between and lose its files.
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		keys, err = u.service.GetAttachmentKeysByNoteID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return u.service.PurgeNoteByID(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.deleteBlobs(ctx, keys...)
+
+	return nil
+}
+
+// PurgeTrash permanently deletes the notes that have been in the trash for
+// longer than the retention period, along with their attached files.
+func (u *NoteUsecase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	trashedBefore := time.Now().UTC().Add(-retention)
+
+	var (
+		keys   []string
+		purged int64
+	)
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		keys, err = u.service.GetTrashedAttachmentKeys(ctx, trashedBefore)
+		if err != nil {
+			return err
+		}
+
+		purged, err = u.service.PurgeTrash(ctx, trashedBefore)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	u.deleteBlobs(ctx, keys...)
+
+	return purged, nil
+}
+
+func (u *NoteUsecase) checkTrashedNoteAuthor(ctx context.Context, id, currentUserID uuid.UUID) error {
+	note, err := u.service.GetTrashedNoteByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if note.Author != currentUserID {
+		return fmt.Errorf("user is not author of this note")
+	}
+
+	return nil
+}
@@ -2,78 +2,306 @@ package usecase
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 	"time"
 
+	"notes-rew/internal/blobstore"
+	"notes-rew/internal/hash"
 	"notes-rew/internal/notes_service/models"
 	"notes-rew/internal/notes_service/service"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrEmptySearchQuery = errors.New("search query is empty")
+	ErrInvalidOffset    = errors.New("offset must not be negative")
+)
+
 type NoteService interface {
 	SaveNoteByID(ctx context.Context, note service.CreateNote) error
 	GetNoteByID(ctx context.Context, id uuid.UUID) (*models.NoteOutput, error)
-	GetAllNotesByAuthorID(ctx context.Context, currentUserID uuid.UUID) ([]models.NoteOutput, error)
-	UpdateNoteByID(ctx context.Context, id uuid.UUID, note service.UpdateNote) error
-	DeleteNoteByID(ctx context.Context, id uuid.UUID) error
+	GetRenderedNote(
+		ctx context.Context,
+		id uuid.UUID,
+		version int64,
+		render func(ctx context.Context) (models.RenderedNote, error),
+	) (models.RenderedNote, error)
+	GetNotesByQuery(ctx context.Context, query service.NotesQuery) ([]models.NoteOutput, error)
+	SearchNotes(ctx context.Context, query service.SearchQuery) ([]models.NoteSearchResult, error)
+	UpdateNoteByID(ctx context.Context, id, authorID uuid.UUID, note service.UpdateNote) (int64, error)
+	DeleteNoteByID(ctx context.Context, id, authorID uuid.UUID, deletedAt time.Time, expectedVersion *int64) error
+	MoveNoteByID(ctx context.Context, id, authorID uuid.UUID, notebookID *uuid.UUID, updatedAt time.Time) error
+	GetNotebookAuthorID(ctx context.Context, notebookID uuid.UUID) (uuid.UUID, error)
+	GetTagCounts(ctx context.Context, authorID uuid.UUID) ([]models.TagCount, error)
+	ReplaceTags(ctx context.Context, authorID uuid.UUID, sources []string, target string, updatedAt time.Time) (int64, error)
+	GetTrashedNoteByID(ctx context.Context, id uuid.UUID) (models.TrashedNote, error)
+	GetTrashedNotesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.TrashedNote, error)
+	RestoreNoteByID(ctx context.Context, id, authorID uuid.UUID) error
+	PurgeNoteByID(ctx context.Context, id uuid.UUID) error
+	PurgeTrash(ctx context.Context, trashedBefore time.Time) (int64, error)
+	CreateRevision(ctx context.Context, noteID, revisionID uuid.UUID, createdAt time.Time) error
+	GetRevisionsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID uuid.UUID, number int) (models.NoteRevision, error)
+	SaveShare(ctx context.Context, share service.CreateShare) error
+	GetShareRole(ctx context.Context, noteID, userID uuid.UUID) (string, error)
+	GetSharesByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteShare, error)
+	DeleteShare(ctx context.Context, noteID, userID uuid.UUID) error
+	GetNotesSharedWithUser(ctx context.Context, userID uuid.UUID) ([]models.SharedNote, error)
+	SaveLink(ctx context.Context, link service.CreateLink) error
+	GetLinkByTokenHash(ctx context.Context, tokenHash string) (models.NoteLink, error)
+	GetLinksByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.NoteLink, error)
+	DeleteLink(ctx context.Context, noteID, linkID uuid.UUID) error
+	SaveAttachment(ctx context.Context, attachment service.CreateAttachment) error
+	GetAttachment(ctx context.Context, noteID, id uuid.UUID) (models.Attachment, error)
+	GetAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]models.Attachment, error)
+	DeleteAttachment(ctx context.Context, noteID, id uuid.UUID) error
+	LockStorageQuota(ctx context.Context, ownerID uuid.UUID) error
+	GetUsedStorage(ctx context.Context, ownerID uuid.UUID) (int64, error)
+	GetAttachmentKeysByNoteID(ctx context.Context, noteID uuid.UUID) ([]string, error)
+	GetTrashedAttachmentKeys(ctx context.Context, trashedBefore time.Time) ([]string, error)
+	ExportNotes(ctx context.Context, authorID uuid.UUID, fn func(note models.ExportedNote) error) error
+	CreateImportJob(ctx context.Context, job service.CreateImportJob) error
+	GetImportJob(ctx context.Context, id uuid.UUID) (models.ImportJob, error)
+	ClaimImportJob(ctx context.Context, staleBefore, now time.Time) (models.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job service.UpdateImportJob) error
+	SaveImportedNote(ctx context.Context, note service.CreateImportedNote) (bool, error)
+	GetNoteChanges(ctx context.Context, authorID uuid.UUID, since int64, limit uint64) ([]models.NoteChange, error)
+	IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// Transactor runs fn in one database transaction carried by its context.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type NoteUsecase struct {
-	service NoteService
+	service    NoteService
+	hasher     hash.Hasher
+	transactor Transactor
+	blobs      blobstore.BlobStore
+	limits     UploadLimits
+	events     EventBus
+	// requireVerifiedEmail keeps unverified accounts from creating notes.
+	requireVerifiedEmail bool
 }
 
 func (u *NoteUsecase) CreateNote(ctx context.Context, req CreateNoteInput) (uuid.UUID, error) {
+	if err := u.checkEmailVerified(ctx, req.Author); err != nil {
+		return uuid.Nil, err
+	}
+
+	if req.NotebookID != nil {
+		if err := u.checkNotebookAuthor(ctx, *req.NotebookID, req.Author); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	id := req.ID
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
 	createNote := service.NewCreateNote(
-		uuid.New(),
+		id,
 		req.Title,
 		req.Body,
-		req.Tags,
+		NormalizeTags(req.Tags),
 		req.Author,
 		time.Now().UTC(),
 		time.Now().UTC(),
 	)
+	createNote.NotebookID = req.NotebookID
 
 	err := u.service.SaveNoteByID(ctx, createNote)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
+	u.publish(ctx, models.NoteCreated, createNote.ID, req.Author, req.Author, 1)
+
 	return createNote.ID, nil
 }
 
+// checkEmailVerified fails with ErrEmailNotVerified when notes may only be
+// created from verified accounts and the user's isn't.
+func (u *NoteUsecase) checkEmailVerified(ctx context.Context, userID uuid.UUID) error {
+	if !u.requireVerifiedEmail {
+		return nil
+	}
+
+	verified, err := u.service.IsEmailVerified(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !verified {
+		return models.ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// ReadNote returns the note if the current user is its author or it was
+// shared with them.
 func (u *NoteUsecase) ReadNote(ctx context.Context, noteID, currentUserID uuid.UUID) (*models.NoteOutput, error) {
-	note, err := u.service.GetNoteByID(ctx, noteID)
+	return u.authorize(ctx, noteID, currentUserID, accessRead)
+}
+
+func (u *NoteUsecase) ReadAllNotes(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	req ListNotesInput,
+) (*models.NotesPage, error) {
+	query, err := newNotesQuery(currentUserID, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if note.Author != currentUserID {
-		return nil, fmt.Errorf("user is not author of this note")
+	// One extra row tells us whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	notes, err := u.service.GetNotesByQuery(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	return note, nil
+	page := &models.NotesPage{Notes: notes}
+
+	if uint64(len(notes)) > limit {
+		page.Notes = notes[:limit]
+		page.NextCursor = encodeNotesCursor(query, page.Notes[limit-1])
+	}
+
+	return page, nil
 }
 
-func (u *NoteUsecase) ReadAllNotes(ctx context.Context, currentUserID uuid.UUID) ([]models.NoteOutput, error) {
-	return u.service.GetAllNotesByAuthorID(ctx, currentUserID)
+func (u *NoteUsecase) SearchNotes(
+	ctx context.Context,
+	currentUserID uuid.UUID,
+	req SearchNotesInput,
+) ([]models.NoteSearchResult, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultNotesLimit
+	}
+
+	if limit < 1 || limit > MaxNotesLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	if req.Offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	return u.service.SearchNotes(ctx, service.SearchQuery{
+		AuthorID: currentUserID,
+		Query:    query,
+		Limit:    uint64(limit),
+		Offset:   uint64(req.Offset),
+	})
 }
 
-func (u *NoteUsecase) UpdateNote(ctx context.Context, id uuid.UUID, req UpdateNoteInput) error {
+// UpdateNote changes the non-nil fields of req and returns the new version
+// of the note. It is allowed to the author and to editors. With
+// ExpectedVersion set, an update racing with another one fails with a
+// VersionConflictError instead of overwriting it.
+func (u *NoteUsecase) UpdateNote(
+	ctx context.Context,
+	id, currentUserID uuid.UUID,
+	req UpdateNoteInput,
+) (int64, error) {
+	note, err := u.authorize(ctx, id, currentUserID, accessWrite)
+	if err != nil {
+		return 0, err
+	}
+
 	noteUpdate, err := NewUpdateNoteInput(req.Title, req.Body, req.Tags)
 	if err != nil {
+		return 0, err
+	}
+	noteUpdate.ExpectedVersion = req.ExpectedVersion
+
+	// An empty patch changes nothing and archives no revision, but still
+	// honours the version check.
+	if noteUpdate.Title == nil && noteUpdate.Body == nil && noteUpdate.Tags == nil {
+		if req.ExpectedVersion != nil && *req.ExpectedVersion != note.Version {
+			return 0, &models.VersionConflictError{CurrentVersion: note.Version}
+		}
+		return note.Version, nil
+	}
+
+	if noteUpdate.Tags != nil {
+		tags := NormalizeTags(*noteUpdate.Tags)
+		noteUpdate.Tags = &tags
+	}
+
+	var version int64
+
+	// The archived revision and the update must not be split by a failure
+	// or by a concurrent update of the same note.
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.service.CreateRevision(ctx, id, uuid.New(), noteUpdate.UpdatedAt); err != nil {
+			return err
+		}
+
+		version, err = u.service.UpdateNoteByID(ctx, id, note.Author, service.UpdateNote(noteUpdate))
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return u.service.UpdateNoteByID(ctx, id, service.UpdateNote(noteUpdate))
+	u.publish(ctx, models.NoteUpdated, id, note.Author, currentUserID, version)
+
+	return version, nil
 }
 
-func (u *NoteUsecase) DeleteNote(ctx context.Context, id uuid.UUID) error {
-	return u.service.DeleteNoteByID(ctx, id)
+// DeleteNote moves the note to the trash. Only the author may do that.
+func (u *NoteUsecase) DeleteNote(ctx context.Context, id, currentUserID uuid.UUID) error {
+	return u.deleteNote(ctx, id, currentUserID, nil)
 }
 
-func NewNoteUsecase(service NoteService) *NoteUsecase {
+// deleteNote moves the note to the trash. With expectedVersion set, it fails
+// with a VersionConflictError if the note was changed in the meantime.
+func (u *NoteUsecase) deleteNote(ctx context.Context, id, currentUserID uuid.UUID, expectedVersion *int64) error {
+	if _, err := u.authorize(ctx, id, currentUserID, accessOwner); err != nil {
+		return err
+	}
+
+	if err := u.service.DeleteNoteByID(ctx, id, currentUserID, time.Now().UTC(), expectedVersion); err != nil {
+		return err
+	}
+
+	u.publish(ctx, models.NoteDeleted, id, currentUserID, currentUserID, 0)
+
+	return nil
+}
+
+func NewNoteUsecase(
+	service NoteService,
+	hasher hash.Hasher,
+	transactor Transactor,
+	blobs blobstore.BlobStore,
+	limits UploadLimits,
+	events EventBus,
+	requireVerifiedEmail bool,
+) *NoteUsecase {
 	return &NoteUsecase{
-		service: service,
+		service:    service,
+		hasher:     hasher,
+		transactor: transactor,
+		blobs:      blobs,
+		limits:     limits,
+		events:     events,
+
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
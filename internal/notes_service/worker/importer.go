@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPollInterval is used when no positive interval is configured,
+// which time.NewTicker would refuse.
+const defaultPollInterval = 2 * time.Second
+
+type ImportUsecase interface {
+	RunNextImport(ctx context.Context, staleAfter time.Duration) (bool, error)
+}
+
+// ImportRunner runs queued import jobs one after another. Several runners,
+// in one process or many, can share the queue.
+type ImportRunner struct {
+	usecase    ImportUsecase
+	interval   time.Duration
+	staleAfter time.Duration
+}
+
+// Run blocks until ctx is cancelled.
+func (r *ImportRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep.
+		for r.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *ImportRunner) runNext(ctx context.Context) bool {
+	ran, err := r.usecase.RunNextImport(ctx, r.staleAfter)
+	if err != nil {
+		logrus.Errorf("error running import job: %v", err)
+		return false
+	}
+
+	return ran
+}
+
+func NewImportRunner(usecase ImportUsecase, interval, staleAfter time.Duration) *ImportRunner {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return &ImportRunner{
+		usecase:    usecase,
+		interval:   interval,
+		staleAfter: staleAfter,
+	}
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPurgeInterval is used when no positive interval is configured,
+// which time.NewTicker would refuse.
+const defaultPurgeInterval = time.Hour
+
+type TrashUsecase interface {
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// TrashPurger periodically removes notes that outlived the trash retention period.
+type TrashPurger struct {
+	usecase   TrashUsecase
+	retention time.Duration
+	interval  time.Duration
+}
+
+// Run blocks until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.usecase.PurgeTrash(ctx, p.retention)
+	if err != nil {
+		logrus.Errorf("error purging trash: %v", err)
+		return
+	}
+
+	if purged > 0 {
+		logrus.Infof("purged %d notes from the trash", purged)
+	}
+}
+
+func NewTrashPurger(usecase TrashUsecase, retention, interval time.Duration) *TrashPurger {
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	return &TrashPurger{
+		usecase:   usecase,
+		retention: retention,
+		interval:  interval,
+	}
+}
@@ -0,0 +1,242 @@
+package token_manager
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const minRSAKeyBits = 2048
+
+var ErrNoSigningKey = errors.New("no signing key is valid now")
+
+// SigningKey is a private key of the key set. It signs access tokens from
+// NotBefore until NotAfter, if set. Tokens it signed are accepted until they
+// expire, so it stays in the JWKS for a while after NotAfter.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (k *SigningKey) signsAt(t time.Time) bool {
+	return !t.Before(k.NotBefore) && (k.NotAfter.IsZero() || t.Before(k.NotAfter))
+}
+
+// verifiesAt reports whether tokens signed by the key may still be alive,
+// grace being how long they live.
+func (k *SigningKey) verifiesAt(t time.Time, grace time.Duration) bool {
+	return k.NotAfter.IsZero() || t.Before(k.NotAfter.Add(grace))
+}
+
+// KeySet holds the keys access tokens are signed with. Keys are rotated by
+// giving the next key a NotBefore ahead of the NotAfter of the current one:
+// it is published in the JWKS before it signs anything, and the current one
+// stays there until its last tokens expire.
+type KeySet struct {
+	keys []SigningKey
+}
+
+// keySetFile is the manifest of a key set. Key files are PEM encoded RSA
+// (PKCS #1 or #8) or Ed25519 (PKCS #8) private keys, relative to the
+// manifest.
+type keySetFile struct {
+	Keys []struct {
+		ID        string    `yaml:"id"`
+		File      string    `yaml:"file"`
+		NotBefore time.Time `yaml:"not_before"`
+		NotAfter  time.Time `yaml:"not_after"`
+	} `yaml:"keys"`
+}
+
+// LoadKeySet reads the key set described by the manifest at path.
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest keySetFile
+	if err = yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse key set %s: %w", path, err)
+	}
+
+	keys := make([]SigningKey, 0, len(manifest.Keys))
+	seen := make(map[string]bool, len(manifest.Keys))
+
+	for _, entry := range manifest.Keys {
+		if entry.ID == "" || seen[entry.ID] {
+			return nil, fmt.Errorf("key set %s: key ids must be set and unique, got %q", path, entry.ID)
+		}
+		seen[entry.ID] = true
+
+		if !entry.NotAfter.IsZero() && !entry.NotAfter.After(entry.NotBefore) {
+			return nil, fmt.Errorf("key %s: not_after must be after not_before", entry.ID)
+		}
+
+		file := entry.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+
+		signer, method, err := loadPrivateKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", entry.ID, err)
+		}
+
+		keys = append(keys, SigningKey{
+			ID:        entry.ID,
+			Method:    method,
+			Private:   signer,
+			NotBefore: entry.NotBefore,
+			NotAfter:  entry.NotAfter,
+		})
+	}
+
+	return NewKeySet(keys...)
+}
+
+// NewKeySet returns a key set of keys, of which one must sign now.
+func NewKeySet(keys ...SigningKey) (*KeySet, error) {
+	sorted := append([]SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].NotBefore.Before(sorted[j].NotBefore)
+	})
+
+	set := &KeySet{keys: sorted}
+
+	if _, err := set.signingKey(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// signingKey returns the newest key that signs at t.
+func (s *KeySet) signingKey(t time.Time) (*SigningKey, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].signsAt(t) {
+			return &s.keys[i], nil
+		}
+	}
+
+	return nil, ErrNoSigningKey
+}
+
+// verificationKey returns the key named kid if its tokens may still be
+// alive at t.
+func (s *KeySet) verificationKey(kid string, t time.Time, grace time.Duration) (*SigningKey, bool) {
+	for i := range s.keys {
+		if s.keys[i].ID == kid && s.keys[i].verifiesAt(t, grace) {
+			return &s.keys[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// RSA keys.
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+	// Ed25519 keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwks returns the public keys that verify tokens at t or will sign soon.
+func (s *KeySet) jwks(t time.Time, grace time.Duration) JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+
+	for i := range s.keys {
+		key := &s.keys[i]
+		if !key.verifiesAt(t, grace) {
+			continue
+		}
+
+		jwk := JWK{
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+			KeyID:     key.ID,
+		}
+
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Modulus = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func loadPrivateKey(file string) (crypto.Signer, jwt.SigningMethod, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, fmt.Errorf("%s: no PEM data", file)
+	}
+
+	var key interface{}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, nil, fmt.Errorf("%s: RSA keys must have at least %d bits", file, minRSAKeyBits)
+		}
+		return key, jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey:
+		return key, jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", file)
+	}
+}
@@ -0,0 +1,422 @@
+package token_manager
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const testTTL = 15 * time.Minute
+
+var (
+	rsaKeyOnce sync.Once
+	rsaKey     *rsa.PrivateKey
+)
+
+// testRSAKey returns a key shared by the tests, as generating one is slow.
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	rsaKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+		if err != nil {
+			t.Fatalf("generate RSA key: %v", err)
+		}
+		rsaKey = key
+	})
+
+	return rsaKey
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+
+	return key
+}
+
+// rotatingKeys returns a key set that retired "old" an hour ago, is signing
+// with "current" and will sign with "next" in an hour.
+func rotatingKeys(t *testing.T, now time.Time) []SigningKey {
+	t.Helper()
+
+	return []SigningKey{
+		{
+			ID:        "old",
+			Method:    jwt.SigningMethodEdDSA,
+			Private:   testEd25519Key(t),
+			NotBefore: now.Add(-48 * time.Hour),
+			NotAfter:  now.Add(-time.Hour),
+		},
+		{
+			ID:        "current",
+			Method:    jwt.SigningMethodRS256,
+			Private:   testRSAKey(t),
+			NotBefore: now.Add(-24 * time.Hour),
+			NotAfter:  now.Add(time.Hour),
+		},
+		{
+			ID:        "next",
+			Method:    jwt.SigningMethodEdDSA,
+			Private:   testEd25519Key(t),
+			NotBefore: now.Add(time.Hour),
+		},
+	}
+}
+
+func TestKeySetSigningKey(t *testing.T) {
+	now := time.Now()
+
+	set, err := NewKeySet(rotatingKeys(t, now)...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "before any key", at: now.Add(-72 * time.Hour)},
+		{name: "old key alone", at: now.Add(-36 * time.Hour), want: "old"},
+		{name: "newest of overlapping keys", at: now.Add(-12 * time.Hour), want: "current"},
+		{name: "after old retired", at: now, want: "current"},
+		{name: "next key", at: now.Add(2 * time.Hour), want: "next"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := set.signingKey(tt.at)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoSigningKey) {
+					t.Fatalf("signingKey error = %v, want %v", err, ErrNoSigningKey)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("signingKey: %v", err)
+			}
+
+			if key.ID != tt.want {
+				t.Errorf("signingKey = %s, want %s", key.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeySetNeedsCurrentKey(t *testing.T) {
+	now := time.Now()
+
+	_, err := NewKeySet(SigningKey{
+		ID:        "future",
+		Method:    jwt.SigningMethodEdDSA,
+		Private:   testEd25519Key(t),
+		NotBefore: now.Add(time.Hour),
+	})
+	if !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("NewKeySet error = %v, want %v", err, ErrNoSigningKey)
+	}
+}
+
+func TestKeySetVerificationKey(t *testing.T) {
+	now := time.Now()
+
+	set, err := NewKeySet(rotatingKeys(t, now)...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		kid   string
+		grace time.Duration
+		ok    bool
+	}{
+		{name: "current key", kid: "current", grace: testTTL, ok: true},
+		{name: "next key", kid: "next", grace: testTTL, ok: true},
+		{name: "retired key within the grace period", kid: "old", grace: 2 * time.Hour, ok: true},
+		{name: "retired key after the grace period", kid: "old", grace: testTTL},
+		{name: "unknown key", kid: "other", grace: testTTL},
+		{name: "no key id", grace: testTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := set.verificationKey(tt.kid, now, tt.grace)
+			if ok != tt.ok {
+				t.Fatalf("verificationKey(%q) ok = %v, want %v", tt.kid, ok, tt.ok)
+			}
+
+			if ok && key.ID != tt.kid {
+				t.Errorf("verificationKey(%q) = %s", tt.kid, key.ID)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	now := time.Now()
+	keys := rotatingKeys(t, now)
+
+	set, err := NewKeySet(keys...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	jwks := set.jwks(now, testTTL)
+
+	got := make(map[string]JWK, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		got[jwk.KeyID] = jwk
+	}
+
+	if _, ok := got["old"]; ok {
+		t.Error("JWKS publishes a key retired longer than a token lifetime ago")
+	}
+
+	current, ok := got["current"]
+	if !ok {
+		t.Fatal("JWKS misses the current key")
+	}
+
+	public := testRSAKey(t).PublicKey
+	if current.KeyType != "RSA" || current.Algorithm != "RS256" || current.Use != "sig" {
+		t.Errorf("current key = %+v, want an RS256 signing key", current)
+	}
+	if n := decodeBigInt(t, current.Modulus); n.Cmp(public.N) != 0 {
+		t.Error("current key modulus doesn't match the private key")
+	}
+	if e := decodeBigInt(t, current.Exponent); e.Int64() != int64(public.E) {
+		t.Errorf("current key exponent = %d, want %d", e.Int64(), public.E)
+	}
+
+	next, ok := got["next"]
+	if !ok {
+		t.Fatal("JWKS misses the next key before it signs")
+	}
+
+	if next.KeyType != "OKP" || next.Curve != "Ed25519" || next.Algorithm != "EdDSA" {
+		t.Errorf("next key = %+v, want an Ed25519 key", next)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(next.X)
+	if err != nil {
+		t.Fatalf("decode x: %v", err)
+	}
+	if !ed25519.PublicKey(x).Equal(keys[2].Private.Public()) {
+		t.Error("next key x doesn't match the private key")
+	}
+}
+
+func TestTokenManagerKeyRotation(t *testing.T) {
+	now := time.Now()
+	keys := rotatingKeys(t, now)
+
+	set, err := NewKeySet(keys...)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	signing := Signing{Keys: set, Issuer: "notes-rew", Audience: "notes-rew"}
+	manager := NewTokenManager(signing, testTTL, time.Hour, nil, nil)
+
+	userID := uuid.NewString()
+
+	token, err := manager.NewJWT(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "current" {
+		t.Errorf("token signed with %v, want current", kid)
+	}
+
+	claims, err := manager.ParseClaims(token)
+	if err != nil {
+		t.Fatalf("ParseClaims: %v", err)
+	}
+	if claims.Subject != userID {
+		t.Errorf("subject = %s, want %s", claims.Subject, userID)
+	}
+
+	sign := func(kid string, method jwt.SigningMethod, key interface{}) string {
+		t.Helper()
+
+		token := jwt.NewWithClaims(method, Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    signing.Issuer,
+				Audience:  jwt.ClaimStrings{signing.Audience},
+				Subject:   userID,
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(testTTL)),
+			},
+		})
+		token.Header["kid"] = kid
+
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign with %s: %v", kid, err)
+		}
+
+		return signed
+	}
+
+	rejected := []struct {
+		name  string
+		token string
+	}{
+		{name: "retired key", token: sign("old", jwt.SigningMethodEdDSA, keys[0].Private)},
+		{name: "unknown key", token: sign("other", jwt.SigningMethodEdDSA, testEd25519Key(t))},
+		{name: "key of another algorithm", token: sign("current", jwt.SigningMethodEdDSA, keys[2].Private)},
+		{name: "HS256 with the public key", token: sign("current", jwt.SigningMethodHS256, []byte("secret"))},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.ParseClaims(tt.token); err == nil {
+				t.Error("ParseClaims accepted the token")
+			}
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(testRSAKey(t))
+	if err != nil {
+		t.Fatalf("marshal RSA key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(testEd25519Key(t))
+	if err != nil {
+		t.Fatalf("marshal Ed25519 key: %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "rsa.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDER}))
+	writeFile(t, filepath.Join(dir, "ed25519.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}))
+
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  bool
+	}{
+		{
+			name: "rotation",
+			manifest: `keys:
+  - id: a
+    file: rsa.pem
+    not_before: 2020-01-01T00:00:00Z
+    not_after: 2021-01-01T00:00:00Z
+  - id: b
+    file: ed25519.pem
+    not_before: 2020-12-01T00:00:00Z
+`,
+		},
+		{
+			name: "duplicate ids",
+			manifest: `keys:
+  - id: a
+    file: rsa.pem
+  - id: a
+    file: ed25519.pem
+`,
+			wantErr: true,
+		},
+		{
+			name: "not_after before not_before",
+			manifest: `keys:
+  - id: a
+    file: rsa.pem
+    not_before: 2021-01-01T00:00:00Z
+    not_after: 2020-01-01T00:00:00Z
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing key file",
+			manifest: `keys:
+  - id: a
+    file: missing.pem
+`,
+			wantErr: true,
+		},
+		{
+			name: "no key signs now",
+			manifest: `keys:
+  - id: a
+    file: rsa.pem
+    not_after: 2020-01-01T00:00:00Z
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "keys.yml")
+			writeFile(t, path, []byte(tt.manifest))
+
+			set, err := LoadKeySet(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadKeySet accepted the manifest")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+
+			key, err := set.signingKey(time.Now())
+			if err != nil {
+				t.Fatalf("signingKey: %v", err)
+			}
+			if key.ID != "b" || key.Method != jwt.SigningMethodEdDSA {
+				t.Errorf("signing key = %s (%s), want b (EdDSA)", key.ID, key.Method.Alg())
+			}
+		})
+	}
+}
+
+func decodeBigInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+
+	return new(big.Int).SetBytes(b)
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,101 @@
+package token_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PersonalTokenPrefix starts every personal access token, which tells them
+// apart from access tokens.
+const PersonalTokenPrefix = "pat_"
+
+// lastUsedResolution is how stale the last use of a personal access token
+// may get before it is written again, so that busy scripts don't cause a
+// write per request.
+const lastUsedResolution = time.Minute
+
+var ErrInvalidPersonalToken = errors.New("personal access token is invalid or expired")
+
+// PersonalToken is a stored personal access token, as far as authenticating
+// with it goes.
+type PersonalToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// PersonalTokenStore finds personal access tokens by the hash of their
+// secret and records when they were last used.
+type PersonalTokenStore interface {
+	GetPersonalToken(ctx context.Context, tokenHash string) (PersonalToken, error)
+	TouchPersonalToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+type PostgresPersonalTokenStore struct {
+	db *pgxpool.Pool
+}
+
+func (s *PostgresPersonalTokenStore) GetPersonalToken(ctx context.Context, tokenHash string) (PersonalToken, error) {
+	sql, args, err := squirrel.Select("id", "user_id", "scopes", "expires_at", "last_used_at").
+		From("personal_access_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return PersonalToken{}, err
+	}
+
+	var token PersonalToken
+
+	err = s.db.QueryRow(ctx, sql, args...).Scan(&token.ID, &token.UserID, &token.Scopes, &token.ExpiresAt, &token.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PersonalToken{}, ErrInvalidPersonalToken
+		}
+		return PersonalToken{}, err
+	}
+
+	return token, nil
+}
+
+func (s *PostgresPersonalTokenStore) TouchPersonalToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	sql, args, err := squirrel.Update("personal_access_tokens").
+		Set("last_used_at", usedAt).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func NewPostgresPersonalTokenStore(db *pgxpool.Pool) *PostgresPersonalTokenStore {
+	return &PostgresPersonalTokenStore{
+		db: db,
+	}
+}
+
+// IsPersonalToken reports whether token is a personal access token rather
+// than an access token.
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
+
+// HashPersonalToken returns what is stored of a personal access token.
+func HashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
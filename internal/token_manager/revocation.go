@@ -0,0 +1,62 @@
+package token_manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	revokedKeyPrefix      = "auth:revoked:"
+	tokenVersionKeyPrefix = "auth:token_version:"
+)
+
+// RevocationStore keeps track of access tokens that were revoked before they
+// expired, either one by one (by jti) or all tokens of a user at once.
+type RevocationStore interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	TokenVersion(ctx context.Context, userID string) (int64, error)
+	BumpTokenVersion(ctx context.Context, userID string) error
+}
+
+type RedisRevocationStore struct {
+	client *redis.Client
+}
+
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.client.Set(ctx, revokedKeyPrefix+jti, 1, ttl).Err()
+}
+
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, revokedKeyPrefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *RedisRevocationStore) TokenVersion(ctx context.Context, userID string) (int64, error) {
+	version, err := s.client.Get(ctx, tokenVersionKeyPrefix+userID).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (s *RedisRevocationStore) BumpTokenVersion(ctx context.Context, userID string) error {
+	return s.client.Incr(ctx, tokenVersionKeyPrefix+userID).Err()
+}
+
+func NewRedisRevocationStore(client *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{
+		client: client,
+	}
+}
@@ -1,57 +1,293 @@
 package token_manager
 
 import (
+	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"math/rand"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"time"
 )
 
-const (
-	tokenTTL = 12 * time.Hour
-)
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// Claims are the claims of an access token. TokenVersion is compared with the
+// user's current token version so that all their tokens can be revoked at once.
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenVersion int64 `json:"ver"`
+}
+
+// Identity is who a request is made for. Personal access tokens are limited
+// to their Scopes; access tokens may do anything the user can.
+type Identity struct {
+	UserID   uuid.UUID
+	Personal bool
+	Scopes   []string
+}
+
+// HasScope reports whether the identity may act within scope.
+func (i *Identity) HasScope(scope string) bool {
+	if !i.Personal {
+		return true
+	}
+
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Signing says how access tokens are signed and whom they are for.
+type Signing struct {
+	// Keys sign tokens with RS256 or EdDSA. Without them tokens are signed
+	// with HS256 and Secret, which every verifier then has to know.
+	Keys     *KeySet
+	Secret   string
+	Issuer   string
+	Audience string
+}
 
 type TokenManager struct {
-	signinKey string
+	signing         Signing
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	revocations     RevocationStore
+	personalTokens  PersonalTokenStore
+}
+
+func (t *TokenManager) NewJWT(ctx context.Context, userID string) (string, error) {
+	version, err := t.tokenVersion(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    t.signing.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.accessTokenTTL)),
+			Subject:   userID,
+		},
+		TokenVersion: version,
+	}
+
+	if t.signing.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{t.signing.Audience}
+	}
+
+	if t.signing.Keys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(t.signing.Secret))
+	}
+
+	key, err := t.signing.Keys.signingKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// ParseToken validates the access token, including its revocation status, and
+// returns the user ID it was issued for.
+func (t *TokenManager) ParseToken(ctx context.Context, accessToken string) (string, error) {
+	claims, err := t.ParseClaims(accessToken)
+	if err != nil {
+		return " uuid.Nil", err
+	}
+
+	if err = t.checkRevoked(ctx, claims); err != nil {
+		return "", err
+	}
+
+	return claims.Subject, nil
 }
 
-func (t *TokenManager) NewJWT(userID string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
-		Subject:   userID,
-	})
+// Authenticate accepts an access token or a personal access token.
+func (t *TokenManager) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if IsPersonalToken(token) {
+		return t.authenticatePersonal(ctx, token)
+	}
+
+	subject, err := t.ParseToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, err
+	}
 
-	return token.SignedString([]byte(t.signinKey))
+	return &Identity{UserID: userID}, nil
 }
 
-func (t *TokenManager) ParseToken(accessToken string) (string, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
+func (t *TokenManager) authenticatePersonal(ctx context.Context, token string) (*Identity, error) {
+	if t.personalTokens == nil {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	stored, err := t.personalTokens.GetPersonalToken(ctx, HashPersonalToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	if stored.ExpiresAt != nil && !stored.ExpiresAt.After(now) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	// Only the time of use is lost if this fails, so the request goes on.
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedResolution {
+		if err = t.personalTokens.TouchPersonalToken(ctx, stored.ID, now); err != nil {
+			logrus.Printf("error recording use of personal access token %s: %s", stored.ID, err)
 		}
+	}
+
+	return &Identity{
+		UserID:   stored.UserID,
+		Personal: true,
+		Scopes:   stored.Scopes,
+	}, nil
+}
 
-		return []byte(t.signinKey), nil
-	})
+// ParseClaims checks the signature, expiry, issuer and audience of the
+// access token only.
+func (t *TokenManager) ParseClaims(accessToken string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(
+		accessToken,
+		&Claims{},
+		t.verificationKey,
+		jwt.WithValidMethods(t.validMethods()),
+		jwt.WithIssuer(t.signing.Issuer),
+		jwt.WithAudience(t.signing.Audience),
+	)
 	if err != nil {
-		return " uuid.Nil", err
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return "", errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
 
-	return claims.Subject, nil
+	return claims, nil
+}
+
+// verificationKey picks the key by the kid header of the token. Keys stay
+// usable for an access token lifetime after they stop signing.
+func (t *TokenManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if t.signing.Keys == nil {
+		return []byte(t.signing.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := t.signing.Keys.verificationKey(kid, time.Now(), t.accessTokenTTL)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if key.Method.Alg() != token.Method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+
+	return key.Private.Public(), nil
+}
+
+func (t *TokenManager) validMethods() []string {
+	if t.signing.Keys == nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+// JWKS returns the public keys verifiers need, including the next key
+// before it starts signing. It is empty with HS256, whose key is secret.
+func (t *TokenManager) JWKS() JWKS {
+	if t.signing.Keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+
+	return t.signing.Keys.jwks(time.Now(), t.accessTokenTTL)
+}
+
+// Revoke makes a single access token unusable for the rest of its lifetime.
+func (t *TokenManager) Revoke(ctx context.Context, claims *Claims) error {
+	if t.revocations == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return t.revocations.Revoke(ctx, claims.ID, ttl)
+}
+
+// RevokeAll makes every access token issued to the user so far unusable.
+func (t *TokenManager) RevokeAll(ctx context.Context, userID string) error {
+	if t.revocations == nil {
+		return nil
+	}
+
+	// The version never expires: if it went back to zero, a later bump
+	// would bring it to a version tokens alive at that time may still carry.
+	return t.revocations.BumpTokenVersion(ctx, userID)
+}
+
+func (t *TokenManager) checkRevoked(ctx context.Context, claims *Claims) error {
+	if t.revocations == nil {
+		return nil
+	}
+
+	revoked, err := t.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	version, err := t.tokenVersion(ctx, claims.Subject)
+	if err != nil {
+		return err
+	}
+	if claims.TokenVersion < version {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+func (t *TokenManager) tokenVersion(ctx context.Context, userID string) (int64, error) {
+	if t.revocations == nil {
+		return 0, nil
+	}
+
+	return t.revocations.TokenVersion(ctx, userID)
 }
 
+// NewRefreshToken returns an opaque random token. Refresh tokens are not JWTs:
+// they are only meaningful to the auth service, which stores their hashes.
 func (t *TokenManager) NewRefreshToken() (string, error) {
 	b := make([]byte, 32)
 
-	s := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(s)
-
-	_, err := r.Read(b)
+	_, err := rand.Read(b)
 	if err != nil {
 		return "", err
 	}
@@ -59,6 +295,25 @@ func (t *TokenManager) NewRefreshToken() (string, error) {
 	return fmt.Sprintf("%x", b), nil
 }
 
-func NewTokenManager(signinKey string) *TokenManager {
-	return &TokenManager{signinKey: signinKey}
+func (t *TokenManager) AccessTokenTTL() time.Duration {
+	return t.accessTokenTTL
+}
+
+func (t *TokenManager) RefreshTokenTTL() time.Duration {
+	return t.refreshTokenTTL
+}
+
+func NewTokenManager(
+	signing Signing,
+	accessTokenTTL, refreshTokenTTL time.Duration,
+	revocations RevocationStore,
+	personalTokens PersonalTokenStore,
+) *TokenManager {
+	return &TokenManager{
+		signing:         signing,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		revocations:     revocations,
+		personalTokens:  personalTokens,
+	}
 }
@@ -0,0 +1,88 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA1 test vectors of RFC 6238, appendix B, cut to the
+// last Digits digits of their eight digit codes.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+
+		if got != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		ok     bool
+	}{
+		{name: "same step", offset: 0, ok: true},
+		{name: "one step later", offset: Period * time.Second, ok: true},
+		{name: "one step earlier", offset: -Period * time.Second, ok: true},
+		{name: "two steps later", offset: 2 * Period * time.Second, ok: false},
+		{name: "two steps earlier", offset: -2 * Period * time.Second, ok: false},
+	}
+
+	for _, vector := range rfcVectors {
+		issued := time.Unix(vector.unix, 0)
+
+		for _, tt := range tests {
+			// Steps count from the Unix epoch; there are none before it.
+			if issued.Add(tt.offset).Unix() < 0 {
+				continue
+			}
+
+			step, ok := Validate(rfcSecret, vector.code, issued.Add(tt.offset))
+			if ok != tt.ok {
+				t.Errorf("%s: Validate of the code at %d = %v, want %v", tt.name, vector.unix, ok, tt.ok)
+				continue
+			}
+
+			if ok && step != Step(issued) {
+				t.Errorf("%s: Validate of the code at %d returned step %d, want %d", tt.name, vector.unix, step, Step(issued))
+			}
+		}
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "94287082"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate accepted %q", code)
+		}
+	}
+}
+
+func TestValidateLowercaseSecret(t *testing.T) {
+	if _, ok := Validate("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", time.Unix(59, 0)); !ok {
+		t.Error("Validate refused a lowercase secret")
+	}
+}
@@ -29,8 +29,10 @@ type UserController struct {
 }
 
 func (c *UserController) Register(r chi.Router) {
+	scopes := middlewares.ReadWrite(middlewares.ScopeUsersRead, "")
+
 	r.Route("/users", func(r chi.Router) {
-		r.Use(middlewares.UserIdentity(c.tokenManager))
+		r.Use(middlewares.UserIdentity(c.tokenManager, scopes))
 		r.Get("/", c.GetUserHandler)
 		r.Patch("/", c.UpdateUserHandler)
 		r.Delete("/", c.DeleteUserHandler)
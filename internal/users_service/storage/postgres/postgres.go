@@ -6,14 +6,21 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"notes-rew/internal/db/transactor"
 	"notes-rew/internal/users_service/models"
 	"notes-rew/internal/users_service/service"
 	"notes-rew/internal/users_service/storage"
 )
 
 type PSQLUserStorage struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
+}
+
+// conn returns the transaction carried by ctx, if any, or the pool.
+func (s *PSQLUserStorage) conn(ctx context.Context) transactor.Querier {
+	return transactor.Conn(ctx, s.db)
 }
 
 func (s *PSQLUserStorage) CreateUserByID(ctx context.Context, user service.CreateUser) error {
@@ -26,7 +33,7 @@ func (s *PSQLUserStorage) CreateUserByID(ctx context.Context, user service.Creat
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
@@ -46,7 +53,7 @@ func (s *PSQLUserStorage) GetUserByID(ctx context.Context, id uuid.UUID) (models
 		return models.UserOutput{}, err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return models.UserOutput{}, err
 	}
@@ -59,6 +66,8 @@ func (s *PSQLUserStorage) UpdateUserByID(ctx context.Context, id uuid.UUID, user
 	sql, args, err := squirrel.Update("users").
 		Set("username", user.Username).
 		Set("email", user.Email).
+		// A new address has to be verified again.
+		Set("email_verified_at", squirrel.Expr("CASE WHEN email = ? THEN email_verified_at END", user.Email)).
 		Set("password", user.Password).
 		Set("updated_at", user.UpdatedAt).
 		Where(squirrel.Eq{"id": id}).
@@ -68,7 +77,7 @@ func (s *PSQLUserStorage) UpdateUserByID(ctx context.Context, id uuid.UUID, user
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
@@ -86,7 +95,7 @@ func (s *PSQLUserStorage) DeleteUserByID(ctx context.Context, id uuid.UUID) erro
 		return err
 	}
 
-	_, err = s.db.Exec(ctx, sql, args...)
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
@@ -106,7 +115,7 @@ func (s *PSQLUserStorage) GetUserForAuth(ctx context.Context, email string) (mod
 		return models.AuthOutput{}, err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return models.AuthOutput{}, errors.New("user not found")
@@ -130,7 +139,7 @@ func (s *PSQLUserStorage) CheckUserByEmail(ctx context.Context, email string) er
 		return err
 	}
 
-	err = s.db.QueryRow(ctx, sql, args...).Scan(&count)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -142,7 +151,7 @@ func (s *PSQLUserStorage) CheckUserByEmail(ctx context.Context, email string) er
 	return nil
 }
 
-func NewPSQLUserStorage(db *pgx.Conn) *PSQLUserStorage {
+func NewPSQLUserStorage(db *pgxpool.Pool) *PSQLUserStorage {
 	return &PSQLUserStorage{
 		db: db,
 	}